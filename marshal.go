@@ -0,0 +1,505 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  struct-tag based encoding on top of the NBT tree  ///////////////////////////////////////////////////////////////////////////
+//
+// Marshal / Unmarshal let callers work with ordinary Go structs instead of hand-building NBT trees; a value is walked with
+// reflect, honoring `nbt:"name,omitempty"` struct tags, and turned into (or populated from) the same NBT tree that
+// ReadNBTData / WriteNBTData already know how to serialize; the anonymous root is always a TAG_Compound, matching the way
+// every Minecraft NBT file begins
+//
+// the field plan for a given type (tag names, indices, omitempty) is computed once via reflect and cached, so repeated
+// Marshal / Unmarshal calls against the same struct type only pay the reflection cost the first time
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Marshaler is implemented by types that know how to encode themselves as an NBT tree; MarshalNBT returns the payload
+// only, the Name field of the result is assigned by the caller (the field name or struct tag, or "" for the root)
+//
+type Marshaler interface {
+	MarshalNBT() (*NBT, error)
+}
+
+// Unmarshaler is implemented by types that know how to populate themselves from an NBT tree
+//
+type Unmarshaler interface {
+	UnmarshalNBT(n *NBT) error
+}
+
+var marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  cached per-type field plans  /////////////////////////////////////////////////////////////////////////////////////////////
+
+// fieldPlan describes a single Go struct field as it relates to NBT encoding: the reflect.Type field index (to support
+// embedded structs, this is a path, not a single int), the name used for the NBT tag, and whether a zero value should be
+// omitted entirely, the way encoding/json handles `omitempty`
+//
+type fieldPlan struct {
+	Index     []int
+	Name      string
+	OmitEmpty bool
+}
+
+// structPlan is the cached reflection result for one struct type : the ordered list of fields that participate in NBT
+// encoding
+//
+type structPlan struct {
+	Fields []fieldPlan
+}
+
+var planCache sync.Map // map[reflect.Type]*structPlan
+
+// planForType builds (or fetches from cache) the structPlan for the given struct type; embedded (anonymous) struct fields
+// are flattened into the parent's field list, so a Player embedding an Entity exposes Entity's tags at the top level
+//
+func planForType(t reflect.Type) *structPlan {
+	if cached, ok := planCache.Load(t); ok {
+		return cached.(*structPlan)
+	}
+
+	plan := &structPlan{}
+	buildFieldPlan(t, nil, plan)
+
+	actual, _ := planCache.LoadOrStore(t, plan)
+	return actual.(*structPlan)
+}
+
+func buildFieldPlan(t reflect.Type, prefix []int, plan *structPlan) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// unexported fields (other than anonymous embeds, handled below) are never part of the NBT encoding
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("nbt")
+		if tag == "-" {
+			continue
+		}
+
+		name, omitempty := parseTag(tag)
+
+		index := make([]int, len(prefix)+1)
+		copy(index, prefix)
+		index[len(prefix)] = i
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			buildFieldPlan(ft, index, plan)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		plan.Fields = append(plan.Fields, fieldPlan{Index: index, Name: name, OmitEmpty: omitempty})
+	}
+}
+
+// parseTag splits a `nbt:"name,omitempty"` struct tag into its name and omitempty components, the same convention used
+// by encoding/json
+//
+func parseTag(tag string) (name string, omitempty bool) {
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Marshal  //////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Marshal walks v with reflect and returns the big-endian NBT encoding of it; v (after dereferencing any pointer) must
+// be a struct or a map[string]T, since the NBT format requires the root item to be a TAG_Compound
+//
+func Marshal(v interface{}) ([]byte, error) {
+	root, err := marshalValue(reflect.ValueOf(v), "")
+	if err != nil {
+		return nil, err
+	}
+
+	if root.Type != TAG_Compound {
+		return nil, fmt.Errorf("nbt: Marshal: root value encodes to %s, not TAG_Compound", root.Type)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteNBTData(buf, root, Java); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// marshalValue converts a single reflect.Value into an *NBT item named 'name'; it is the recursive workhorse behind
+// both Marshal and the TAG_List / TAG_Compound cases below
+//
+func marshalValue(rv reflect.Value, name string) (*NBT, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("nbt: Marshal: %q: nil pointer/interface has no NBT representation", name)
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.IsValid() && rv.Type().Implements(marshalerType) {
+		n, err := rv.Interface().(Marshaler).MarshalNBT()
+		if err != nil {
+			return nil, err
+		}
+		n.Name = name
+		return n, nil
+	}
+	if rv.CanAddr() && rv.Addr().Type().Implements(marshalerType) {
+		n, err := rv.Addr().Interface().(Marshaler).MarshalNBT()
+		if err != nil {
+			return nil, err
+		}
+		n.Name = name
+		return n, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Int8:
+		return &NBT{Type: TAG_Byte, Name: name, Data: byte(rv.Int())}, nil
+	case reflect.Int16:
+		return &NBT{Type: TAG_Short, Name: name, Data: int16(rv.Int())}, nil
+	case reflect.Int32, reflect.Int:
+		return &NBT{Type: TAG_Int, Name: name, Data: int32(rv.Int())}, nil
+	case reflect.Int64:
+		return &NBT{Type: TAG_Long, Name: name, Data: rv.Int()}, nil
+	case reflect.Float32:
+		return &NBT{Type: TAG_Float, Name: name, Data: float32(rv.Float())}, nil
+	case reflect.Float64:
+		return &NBT{Type: TAG_Double, Name: name, Data: rv.Float()}, nil
+	case reflect.String:
+		return &NBT{Type: TAG_String, Name: name, Data: rv.String()}, nil
+
+	case reflect.Slice, reflect.Array:
+		return marshalSlice(rv, name)
+
+	case reflect.Map:
+		return marshalMap(rv, name)
+
+	case reflect.Struct:
+		return marshalStruct(rv, name)
+	}
+
+	return nil, fmt.Errorf("nbt: Marshal: %q: unsupported kind %s", name, rv.Kind())
+}
+
+func marshalSlice(rv reflect.Value, name string) (*NBT, error) {
+	switch rv.Type().Elem().Kind() {
+	case reflect.Uint8:
+		data := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(data), rv)
+		return &NBT{Type: TAG_Byte_Array, Name: name, Size: uint32(len(data)), Data: data}, nil
+
+	case reflect.Int32:
+		data := make([]int32, rv.Len())
+		reflect.Copy(reflect.ValueOf(data), rv)
+		return &NBT{Type: TAG_Int_Array, Name: name, Size: uint32(len(data)), Data: data}, nil
+
+	case reflect.Int64:
+		data := make([]int64, rv.Len())
+		reflect.Copy(reflect.ValueOf(data), rv)
+		return &NBT{Type: TAG_Long_Array, Name: name, Size: uint32(len(data)), Data: data}, nil
+	}
+
+	elems := make([]NBT, rv.Len())
+	listType := TAG_End
+
+	for i := 0; i < rv.Len(); i++ {
+		elem, err := marshalValue(rv.Index(i), "LISTELEM")
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			listType = elem.Type
+		}
+		elems[i] = *elem
+	}
+
+	return &NBT{Type: TAG_List, List: listType, Name: name, Size: uint32(len(elems)), Data: elems}, nil
+}
+
+func marshalMap(rv reflect.Value, name string) (*NBT, error) {
+	if rv.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("nbt: Marshal: %q: map key must be string, got %s", name, rv.Type().Key())
+	}
+
+	keys := make([]string, 0, rv.Len())
+	for _, k := range rv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	elems := make([]NBT, 0, len(keys))
+	for _, k := range keys {
+		val := rv.MapIndex(reflect.ValueOf(k))
+		elem, err := marshalValue(val, k)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, *elem)
+	}
+
+	return &NBT{Type: TAG_Compound, Name: name, Size: uint32(len(elems)), Data: elems}, nil
+}
+
+func marshalStruct(rv reflect.Value, name string) (*NBT, error) {
+	plan := planForType(rv.Type())
+
+	elems := make([]NBT, 0, len(plan.Fields))
+	for _, fp := range plan.Fields {
+		fv := rv.FieldByIndex(fp.Index)
+
+		if fp.OmitEmpty && fv.IsZero() {
+			continue
+		}
+
+		elem, err := marshalValue(fv, fp.Name)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, *elem)
+	}
+
+	return &NBT{Type: TAG_Compound, Name: name, Size: uint32(len(elems)), Data: elems}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Unmarshal  ////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Unmarshal parses the big-endian NBT encoding in data and stores the result in v, which must be a non-nil pointer to a
+// struct or a map[string]T
+//
+func Unmarshal(data []byte, v interface{}) error {
+	root, err := ReadNBTData(bytes.NewReader(data), TAG_NULL, "", Java)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("nbt: Unmarshal: v must be a non-nil pointer, got %T", v)
+	}
+
+	return unmarshalValue(&root, rv.Elem())
+}
+
+// unmarshalValue populates rv from n; it is the recursive workhorse behind Unmarshal
+//
+func unmarshalValue(n *NBT, rv reflect.Value) error {
+	if rv.CanAddr() && rv.Addr().Type().Implements(unmarshalerType) {
+		return rv.Addr().Interface().(Unmarshaler).UnmarshalNBT(n)
+	}
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Int8:
+		if n.Type != TAG_Byte {
+			return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into int8", n.Name, n.Type)
+		}
+		rv.SetInt(int64(int8(n.Data.(byte))))
+	case reflect.Int16:
+		if n.Type != TAG_Short {
+			return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into int16", n.Name, n.Type)
+		}
+		rv.SetInt(int64(n.Data.(int16)))
+	case reflect.Int32, reflect.Int:
+		if n.Type != TAG_Int {
+			return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into %s", n.Name, n.Type, rv.Kind())
+		}
+		rv.SetInt(int64(n.Data.(int32)))
+	case reflect.Int64:
+		if n.Type != TAG_Long {
+			return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into int64", n.Name, n.Type)
+		}
+		rv.SetInt(n.Data.(int64))
+	case reflect.Float32:
+		if n.Type != TAG_Float {
+			return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into float32", n.Name, n.Type)
+		}
+		rv.SetFloat(float64(n.Data.(float32)))
+	case reflect.Float64:
+		if n.Type != TAG_Double {
+			return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into float64", n.Name, n.Type)
+		}
+		rv.SetFloat(n.Data.(float64))
+	case reflect.String:
+		if n.Type != TAG_String {
+			return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into string", n.Name, n.Type)
+		}
+		rv.SetString(n.Data.(string))
+
+	case reflect.Slice, reflect.Array:
+		return unmarshalSlice(n, rv)
+
+	case reflect.Map:
+		return unmarshalMap(n, rv)
+
+	case reflect.Struct:
+		return unmarshalStruct(n, rv)
+
+	default:
+		return fmt.Errorf("nbt: Unmarshal: %q: unsupported kind %s", n.Name, rv.Kind())
+	}
+
+	return nil
+}
+
+func unmarshalSlice(n *NBT, rv reflect.Value) error {
+	switch n.Type {
+	case TAG_Byte_Array:
+		src := n.Data.([]byte)
+		rv.Set(reflect.MakeSlice(rv.Type(), len(src), len(src)))
+		reflect.Copy(rv, reflect.ValueOf(src))
+		return nil
+
+	case TAG_Int_Array:
+		src := n.Data.([]int32)
+		rv.Set(reflect.MakeSlice(rv.Type(), len(src), len(src)))
+		reflect.Copy(rv, reflect.ValueOf(src))
+		return nil
+
+	case TAG_Long_Array:
+		src := n.Data.([]int64)
+		rv.Set(reflect.MakeSlice(rv.Type(), len(src), len(src)))
+		reflect.Copy(rv, reflect.ValueOf(src))
+		return nil
+
+	case TAG_List:
+		src := n.Data.([]NBT)
+		rv.Set(reflect.MakeSlice(rv.Type(), len(src), len(src)))
+		for i := range src {
+			if err := unmarshalValue(&src[i], rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into %s", n.Name, n.Type, rv.Type())
+}
+
+func unmarshalMap(n *NBT, rv reflect.Value) error {
+	if n.Type != TAG_Compound {
+		return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into map", n.Name, n.Type)
+	}
+
+	rv.Set(reflect.MakeMap(rv.Type()))
+
+	for _, elem := range n.Data.([]NBT) {
+		val := reflect.New(rv.Type().Elem()).Elem()
+		if err := unmarshalValue(&elem, val); err != nil {
+			return err
+		}
+		rv.SetMapIndex(reflect.ValueOf(elem.Name), val)
+	}
+
+	return nil
+}
+
+func unmarshalStruct(n *NBT, rv reflect.Value) error {
+	if n.Type != TAG_Compound {
+		return fmt.Errorf("nbt: Unmarshal: %q: cannot decode %s into struct", n.Name, n.Type)
+	}
+
+	plan := planForType(rv.Type())
+
+	byName := make(map[string]fieldPlan, len(plan.Fields))
+	for _, fp := range plan.Fields {
+		byName[fp.Name] = fp
+	}
+
+	for _, elem := range n.Data.([]NBT) {
+		fp, ok := byName[elem.Name]
+		if !ok {
+			// fields present in the data but absent from the struct are silently ignored, the same way
+			// encoding/json handles unknown keys
+			continue
+		}
+
+		if err := unmarshalValue(&elem, rv.FieldByIndex(fp.Index)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  streaming Encoder / Decoder  //////////////////////////////////////////////////////////////////////////////////////////////
+
+// Encoder writes the Marshal encoding of successive values to an underlying io.Writer
+//
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an Encoder that writes to w
+//
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode marshals v and writes the result to the Encoder's writer
+//
+func (e *Encoder) Encode(v interface{}) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(data)
+	return err
+}
+
+// Decoder reads a single NBT-encoded value from an underlying io.Reader
+//
+type Decoder struct {
+	r io.Reader
+}
+
+// NewDecoder returns a Decoder that reads from r
+//
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode reads the whole of the Decoder's reader and unmarshals it into v
+//
+func (d *Decoder) Decode(v interface{}) error {
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+
+	return Unmarshal(data, v)
+}
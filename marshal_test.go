@@ -0,0 +1,145 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Marshal / Unmarshal round-trip tests  ////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Player is a small stand-in for the relevant part of a Minecraft player.dat file
+type Player struct {
+	Health   float32  `nbt:"Health"`
+	Food     int32    `nbt:"foodLevel"`
+	Pos      []float64
+	Inventory []Item
+}
+
+type Item struct {
+	ID     string `nbt:"id"`
+	Count  int8   `nbt:"Count"`
+	Damage int16  `nbt:"Damage,omitempty"`
+}
+
+// Section is a stand-in for one vertical slice of a Chunk
+type Section struct {
+	Y        int8
+	Blocks   []byte `nbt:"Blocks"`
+	Data     []byte `nbt:"Data"`
+	BlockLight []byte
+}
+
+// Chunk is a stand-in for the relevant part of a Minecraft region chunk
+type Chunk struct {
+	Sections  []Section
+	Biomes    []int32
+	LastUpdate int64 `nbt:"LastUpdate"`
+}
+
+// Level is a stand-in for the relevant part of a Minecraft level.dat file
+type Level struct {
+	LevelName string `nbt:"LevelName"`
+	Seed      int64  `nbt:"RandomSeed"`
+	SpawnX    int32
+	SpawnY    int32
+	SpawnZ    int32
+	Raining   bool `nbt:"-"`
+}
+
+func TestMarshalUnmarshalPlayer(t *testing.T) {
+	want := Player{
+		Health: 20,
+		Food:   18,
+		Pos:    []float64{12.5, 64, -400.25},
+		Inventory: []Item{
+			{ID: "minecraft:diamond_pickaxe", Count: 1, Damage: 3},
+			{ID: "minecraft:torch", Count: 64},
+		},
+	}
+
+	data, err := Marshal(&want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got Player
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("round-trip mismatch:\n want %+v\n got  %+v", want, got)
+	}
+}
+
+func TestMarshalUnmarshalChunk(t *testing.T) {
+	want := Chunk{
+		Sections: []Section{
+			{Y: 0, Blocks: []byte{1, 2, 3}, Data: []byte{0, 0, 0}, BlockLight: []byte{15, 15}},
+			{Y: 1, Blocks: []byte{0, 0}, Data: []byte{0, 0}, BlockLight: []byte{0, 0}},
+		},
+		Biomes:     []int32{1, 1, 4, 4},
+		LastUpdate: 123456789,
+	}
+
+	data, err := Marshal(&want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got Chunk
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("round-trip mismatch:\n want %+v\n got  %+v", want, got)
+	}
+}
+
+func TestMarshalUnmarshalLevel(t *testing.T) {
+	want := Level{
+		LevelName: "New World",
+		Seed:      -8019191920289085320,
+		SpawnX:    12,
+		SpawnY:    64,
+		SpawnZ:    -3,
+	}
+
+	data, err := Marshal(&want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got Level
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	// Raining is tagged "-" and so never round-trips; zero it out on both sides before comparing
+	want.Raining = false
+	got.Raining = false
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("round-trip mismatch:\n want %+v\n got  %+v", want, got)
+	}
+}
+
+// TestUnmarshalTypeMismatch verifies that decoding a field whose on-disk TAG doesn't match the Go field's expected
+// TAG returns an error instead of panicking on the underlying type assertion
+func TestUnmarshalTypeMismatch(t *testing.T) {
+	data, err := Marshal(&struct {
+		Health int32 `nbt:"Health"`
+	}{Health: 20})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got struct {
+		Health int8 `nbt:"Health"`
+	}
+	if err := Unmarshal(data, &got); err == nil {
+		t.Fatalf("Unmarshal: expected an error decoding TAG_Int into int8, got nil")
+	}
+}
@@ -4,13 +4,14 @@ package nbt
 //  import necessary external packages  ///////////////////////////////////////////////////////////////////////////////////////
 
 import (
+	"bufio"
 	"bytes"
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"reflect"
 	"strconv"
+	"sync"
 )
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -270,7 +271,104 @@ func (nbt *NBT) UnmarshalJSON(b []byte) (err error) {
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 // define library functions
 //
-func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error) {
+// ReadNBTData and WriteNBTData take a plain io.Reader / io.Writer, the same way encoding/gob and every other binary
+// codec in the ecosystem do, so a caller can stream directly from an os.File, a gzip.Reader, a network socket, or an
+// io.LimitReader without first copying an entire world into a []byte
+
+// byteReader is what the recursive read path actually needs : plain byte-at-a-time reads for TAG markers and List
+// type bytes, plus whatever each codec's fixed-width or VarInt decoding requires; bufio.Reader satisfies it, and so
+// does *bytes.Reader, so wrapping is only necessary for callers that hand in a bare io.Reader
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func asByteReader(r io.Reader) byteReader {
+	if br, ok := r.(byteReader); ok {
+		return br
+	}
+	return bufio.NewReader(r)
+}
+
+// scratch8Pool holds reusable 8-byte arrays, the maximum width any fixed-width NBT field needs (TAG_Long, TAG_Double);
+// every codec's fixed-width Read*/Write* method borrows one instead of letting encoding/binary allocate a fresh value
+// per call, which otherwise dominates allocations when parsing a multi-megabyte region file
+var scratch8Pool = sync.Pool{
+	New: func() interface{} { return new([8]byte) },
+}
+
+// readScratch reads n bytes (n <= 8) into a pooled scratch array and calls decode with the filled slice before
+// returning the array to the pool; decode must not retain the slice it's given, since the backing array can be
+// handed out to a concurrent readScratch/writeScratch call (by another goroutine) the instant this call returns.
+// Earlier versions returned the raw slice directly and let the caller decode it after readScratch's defer had
+// already released the backing array back to the pool; under concurrent use (e.g. parsing multiple chunks/files in
+// parallel, which is exactly the streaming use case pooling exists for) another goroutine's Get() could land on
+// and overwrite that array before the first caller finished decoding it, a data race confirmed under -race
+func readScratch(r io.Reader, n int, decode func(b []byte)) error {
+	b := scratch8Pool.Get().(*[8]byte)
+	defer scratch8Pool.Put(b)
+
+	if _, err := io.ReadFull(r, b[:n]); err != nil {
+		return err
+	}
+	decode(b[:n])
+	return nil
+}
+
+// writeScratch hands fill a pooled scratch array (n <= 8 bytes) and writes the result to w
+func writeScratch(w io.Writer, n int, fill func(b []byte)) error {
+	b := scratch8Pool.Get().(*[8]byte)
+	defer scratch8Pool.Put(b)
+
+	fill(b[:n])
+	_, err := w.Write(b[:n])
+	return err
+}
+
+// stringScratchPool holds reusable byte slices for the transient read-then-convert-to-string step TAG_String values
+// and compound-item names both require; unlike the array data types, the raw bytes never escape as NBT.Data (string()
+// always makes its own copy), so the backing slice is always safe to return to the pool afterward
+var stringScratchPool = sync.Pool{
+	New: func() interface{} { s := make([]byte, 0, 256); return &s },
+}
+
+// readString reads n bytes from r and returns them as a string, using a pooled buffer for the transient read
+func readString(r io.Reader, n int) (string, error) {
+	if n == 0 {
+		return "", nil
+	}
+
+	bp := stringScratchPool.Get().(*[]byte)
+	defer stringScratchPool.Put(bp)
+
+	if cap(*bp) < n {
+		*bp = make([]byte, n)
+	} else {
+		*bp = (*bp)[:n]
+	}
+
+	if _, err := io.ReadFull(r, *bp); err != nil {
+		return "", err
+	}
+	return string(*bp), nil
+}
+
+func ReadNBTData(r io.Reader, t NBTTAG, debug string, variant Variant) (NBT, error) {
+	return readNBTData(asByteReader(r), t, debug, variant)
+}
+
+// ReadNBTDataBytes is a thin shim for callers still passing the old *bytes.Reader entry point by name;
+// ReadNBTData now accepts the wider io.Reader directly and satisfies *bytes.Reader callers without this shim, so
+// prefer calling it instead.
+//
+// Deprecated: use ReadNBTData.
+func ReadNBTDataBytes(r *bytes.Reader, t NBTTAG, debug string, variant Variant) (NBT, error) {
+	return ReadNBTData(r, t, debug, variant)
+}
+
+func readNBTData(r byteReader, t NBTTAG, debug string, variant Variant) (rtrn NBT, err error) {
+	c := variant.codec()
+
 	var tb byte
 	var tt NBTTAG
 
@@ -305,22 +403,16 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 	// the use of the input parameter 't' as a sentinal value for TAG_List elements is used here, too, since TAG_List
 	// elements are nameless, which is differnet from haveing a name of "" : there isn't even a name-length indicator
 	//
-	var strlen int16
+	var strlen int
 	var name string
 	if t == TAG_NULL {
-		err = binary.Read(r, binary.BigEndian, &strlen)
+		strlen, err = c.ReadStringLen(r)
 		if err != nil {
 			return rtrn, err
 		}
-		if strlen > 0 {
-			data := make([]byte, strlen)
-			_, err = io.ReadFull(r, data)
-			if err != nil {
-				return rtrn, err
-			}
-			name = string(data)
-		} else {
-			name = ""
+		name, err = readString(r, strlen)
+		if err != nil {
+			return rtrn, err
 		}
 	} else {
 		// since an emtpy string is a valid name, we use this as a sentinal value when writing NBT items back out
@@ -348,7 +440,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 
 	case TAG_Short:
 		var datashort int16
-		err = binary.Read(r, binary.BigEndian, &datashort)
+		datashort, err = c.ReadInt16(r)
 		if err != nil {
 			return rtrn, err
 		}
@@ -357,7 +449,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 
 	case TAG_Int:
 		var dataint int32
-		err = binary.Read(r, binary.BigEndian, &dataint)
+		dataint, err = c.ReadInt32(r)
 		if err != nil {
 			return rtrn, err
 		}
@@ -366,7 +458,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 
 	case TAG_Long:
 		var datalong int64
-		err = binary.Read(r, binary.BigEndian, &datalong)
+		datalong, err = c.ReadInt64(r)
 		if err != nil {
 			return rtrn, err
 		}
@@ -375,7 +467,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 
 	case TAG_Float:
 		var datafloat float32
-		err = binary.Read(r, binary.BigEndian, &datafloat)
+		datafloat, err = c.ReadFloat32(r)
 		if err != nil {
 			return rtrn, err
 		}
@@ -384,7 +476,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 
 	case TAG_Double:
 		var datadouble float64
-		err = binary.Read(r, binary.BigEndian, &datadouble)
+		datadouble, err = c.ReadFloat64(r)
 		if err != nil {
 			return rtrn, err
 		}
@@ -392,31 +484,28 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 		rtrn.Data = datadouble
 
 	case TAG_String:
-		var strlen int16
-		err = binary.Read(r, binary.BigEndian, &strlen)
+		var strlen int
+		strlen, err = c.ReadStringLen(r)
 		if err != nil {
 			return rtrn, err
 		}
 		rtrn.Size = uint32(strlen)
 
-		data := make([]byte, strlen)
-		_, err = io.ReadFull(r, data)
+		rtrn.Data, err = readString(r, strlen)
 		if err != nil {
 			return rtrn, err
 		}
 
-		rtrn.Data = string(data)
-
 	case TAG_Byte_Array:
 		var sizeint uint32
-		err = binary.Read(r, binary.BigEndian, &sizeint)
+		sizeint, err = c.ReadSize(r)
 		if err != nil {
 			return rtrn, err
 		}
 		rtrn.Size = sizeint
 
 		arraybyte := make([]byte, sizeint)
-		err = binary.Read(r, binary.BigEndian, &arraybyte)
+		_, err = io.ReadFull(r, arraybyte)
 		if err != nil {
 			return rtrn, err
 		}
@@ -425,32 +514,36 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 
 	case TAG_Int_Array:
 		var sizeint uint32
-		err = binary.Read(r, binary.BigEndian, &sizeint)
+		sizeint, err = c.ReadSize(r)
 		if err != nil {
 			return rtrn, err
 		}
 		rtrn.Size = sizeint
 
 		arrayint := make([]int32, sizeint)
-		err = binary.Read(r, binary.BigEndian, &arrayint)
-		if err != nil {
-			return rtrn, err
+		for indx := range arrayint {
+			arrayint[indx], err = c.ReadInt32(r)
+			if err != nil {
+				return rtrn, err
+			}
 		}
 
 		rtrn.Data = arrayint
 
 	case TAG_Long_Array:
 		var sizeint uint32
-		err = binary.Read(r, binary.BigEndian, &sizeint)
+		sizeint, err = c.ReadSize(r)
 		if err != nil {
 			return rtrn, err
 		}
 		rtrn.Size = sizeint
 
 		arraylong := make([]int64, sizeint)
-		err = binary.Read(r, binary.BigEndian, &arraylong)
-		if err != nil {
-			return rtrn, err
+		for indx := range arraylong {
+			arraylong[indx], err = c.ReadInt64(r)
+			if err != nil {
+				return rtrn, err
+			}
 		}
 
 		rtrn.Data = arraylong
@@ -466,7 +559,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 		rtrn.List = NBTTAG(id)
 
 		var sizeint uint32
-		err = binary.Read(r, binary.BigEndian, &sizeint)
+		sizeint, err = c.ReadSize(r)
 		if err != nil {
 			return rtrn, err
 		}
@@ -479,7 +572,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 		// manifests the hierarchical nature of the NBT encoding scheme;  for these List elements, though, we send
 		// in the TAG_Type of the List elements; see code comments at the top of this function for more detail why
 		for indx := 0; indx < int(sizeint); indx++ {
-			listnbt[indx], err = ReadNBTData(r, NBTTAG(id), debug)
+			listnbt[indx], err = readNBTData(r, NBTTAG(id), debug, variant)
 			if err != nil {
 				return rtrn, err
 			}
@@ -498,7 +591,7 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 			// we use a recursive call to this function to read in the Compound elements; along with TAG_List,
 			// this manifests the hierarchical nature of the NBT encoding scheme;  unlike TAG_List, each
 			// TAG_Compound element is a fully-formed NBT item, so we call ReadNBTData() in the normal manner
-			nbt, err = ReadNBTData(r, TAG_NULL, debug)
+			nbt, err = readNBTData(r, TAG_NULL, debug, variant)
 			if err != nil {
 				return rtrn, err
 			}
@@ -526,7 +619,22 @@ func ReadNBTData(r *bytes.Reader, t NBTTAG, debug string) (rtrn NBT, err error)
 	return rtrn, err
 }
 
-func WriteNBTData(buf *bytes.Buffer, src *NBT) (err error) {
+func WriteNBTData(w io.Writer, src *NBT, variant Variant) error {
+	return writeNBTData(w, src, variant)
+}
+
+// WriteNBTDataBytes is a thin shim for callers still passing the old *bytes.Buffer entry point by name;
+// WriteNBTData now accepts the wider io.Writer directly and satisfies *bytes.Buffer callers without this shim, so
+// prefer calling it instead.
+//
+// Deprecated: use WriteNBTData.
+func WriteNBTDataBytes(w *bytes.Buffer, src *NBT, variant Variant) error {
+	return WriteNBTData(w, src, variant)
+}
+
+func writeNBTData(w io.Writer, src *NBT, variant Variant) (err error) {
+	c := variant.codec()
+
 	// if we reach this point with an NBTTAG bearing our internal NULL-type TAG or nil data,
 	// something went wrong somewhere, so we abend
 	if src.Type == TAG_NULL {
@@ -544,7 +652,7 @@ func WriteNBTData(buf *bytes.Buffer, src *NBT) (err error) {
 	// otherwise, it is a named TAG, so before storing the payload, we store the TAG type, the length of the name and the
 	// name itself; although the name might be zero-length
 	if src.Name != "LISTELEM" {
-		err = binary.Write(buf, binary.BigEndian, byte(src.Type))
+		_, err = w.Write([]byte{byte(src.Type)})
 		if err != nil {
 			return err
 		}
@@ -555,13 +663,13 @@ func WriteNBTData(buf *bytes.Buffer, src *NBT) (err error) {
 		}
 
 		strlen := len(src.Name)
-		err = binary.Write(buf, binary.BigEndian, int16(strlen))
+		err = c.WriteStringLen(w, strlen)
 		if err != nil {
 			return err
 		}
 
 		if strlen > 0 {
-			_, err = buf.WriteString(src.Name)
+			_, err = io.WriteString(w, src.Name)
 			if err != nil {
 				return err
 			}
@@ -570,96 +678,100 @@ func WriteNBTData(buf *bytes.Buffer, src *NBT) (err error) {
 
 	switch src.Type {
 	case TAG_Byte:
-		err = binary.Write(buf, binary.BigEndian, src.Data.(byte))
+		_, err = w.Write([]byte{src.Data.(byte)})
 		if err != nil {
 			return err
 		}
 
 	case TAG_Short:
-		err = binary.Write(buf, binary.BigEndian, src.Data.(int16))
+		err = c.WriteInt16(w, src.Data.(int16))
 		if err != nil {
 			return err
 		}
 
 	case TAG_Int:
-		err = binary.Write(buf, binary.BigEndian, src.Data.(int32))
+		err = c.WriteInt32(w, src.Data.(int32))
 		if err != nil {
 			return err
 		}
 
 	case TAG_Long:
-		err = binary.Write(buf, binary.BigEndian, src.Data.(int64))
+		err = c.WriteInt64(w, src.Data.(int64))
 		if err != nil {
 			return err
 		}
 
 	case TAG_Float:
-		err = binary.Write(buf, binary.BigEndian, src.Data.(float32))
+		err = c.WriteFloat32(w, src.Data.(float32))
 		if err != nil {
 			return err
 		}
 
 	case TAG_Double:
-		err = binary.Write(buf, binary.BigEndian, src.Data.(float64))
+		err = c.WriteFloat64(w, src.Data.(float64))
 		if err != nil {
 			return err
 		}
 
 	case TAG_String:
 		strlen := len(src.Data.(string))
-		err = binary.Write(buf, binary.BigEndian, int16(strlen))
+		err = c.WriteStringLen(w, strlen)
 		if err != nil {
 			return err
 		}
 
 		if strlen > 0 {
-			_, err = buf.WriteString(src.Data.(string))
+			_, err = io.WriteString(w, src.Data.(string))
 			if err != nil {
 				return err
 			}
 		}
 
 	case TAG_Byte_Array:
-		err = binary.Write(buf, binary.BigEndian, src.Size)
+		err = c.WriteSize(w, src.Size)
 		if err != nil {
 			return err
 		}
 
-		err = binary.Write(buf, binary.BigEndian, src.Data.([]byte))
+		_, err = w.Write(src.Data.([]byte))
 		if err != nil {
 			return err
 		}
 
 	case TAG_Int_Array:
-		err = binary.Write(buf, binary.BigEndian, src.Size)
+		err = c.WriteSize(w, src.Size)
 		if err != nil {
 			return err
 		}
 
-		err = binary.Write(buf, binary.BigEndian, src.Data.([]int32))
-		if err != nil {
-			return err
+		for _, elem := range src.Data.([]int32) {
+			err = c.WriteInt32(w, elem)
+			if err != nil {
+				return err
+			}
 		}
 
 	case TAG_Long_Array:
-		err = binary.Write(buf, binary.BigEndian, src.Size)
+		err = c.WriteSize(w, src.Size)
 		if err != nil {
 			return err
 		}
 
-		err = binary.Write(buf, binary.BigEndian, src.Data.([]int64))
-		if err != nil {
-			return err
+		for _, elem := range src.Data.([]int64) {
+			err = c.WriteInt64(w, elem)
+			if err != nil {
+				return err
+			}
 		}
 
 	case TAG_List:
 		id := src.List
-		err = binary.Write(buf, binary.BigEndian, byte(id))
+		_, err = w.Write([]byte{byte(id)})
 		if err != nil {
 			return err
 		}
 
-		err = binary.Write(buf, binary.BigEndian, src.Size)
+		err = c.WriteSize(w, src.Size)
 		if err != nil {
 			return err
 		}
@@ -667,7 +779,7 @@ func WriteNBTData(buf *bytes.Buffer, src *NBT) (err error) {
 		arrlen := len(src.Data.([]NBT))
 		for indx := 0; indx < int(arrlen); indx++ {
 			elem := src.Data.([]NBT)[indx]
-			err = WriteNBTData(buf, &elem)
+			err = writeNBTData(w, &elem, variant)
 			if err != nil {
 				return err
 			}
@@ -675,14 +787,14 @@ func WriteNBTData(buf *bytes.Buffer, src *NBT) (err error) {
 
 	case TAG_Compound:
 		for _, elem := range src.Data.([]NBT) {
-			err = WriteNBTData(buf, &elem)
+			err = writeNBTData(w, &elem, variant)
 			if err != nil {
 				return err
 			}
 		}
 		// we used the TAG_End at the end of a collection of TAG_Compound elements to break out of the reading loop;
 		// so, we have not stored it; so, we write out a TAG_End NBT item after writing out all the Compound elements
-		err = binary.Write(buf, binary.BigEndian, byte(TAG_End))
+		_, err = w.Write([]byte{byte(TAG_End)})
 		if err != nil {
 			return err
 		}
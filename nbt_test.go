@@ -0,0 +1,84 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  core ReadNBTData / WriteNBTData tests  ///////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestDeprecatedBytesShims checks that the deprecated *bytes.Reader / *bytes.Buffer entry points still behave
+// identically to the io.Reader / io.Writer based ReadNBTData / WriteNBTData they now forward to
+func TestDeprecatedBytesShims(t *testing.T) {
+	src := &NBT{Type: TAG_Compound, Data: []NBT{
+		{Type: TAG_String, Name: "Name", Data: "Steve"},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteNBTDataBytes(&buf, src, Java); err != nil {
+		t.Fatalf("WriteNBTDataBytes: %v", err)
+	}
+
+	got, err := ReadNBTDataBytes(bytes.NewReader(buf.Bytes()), TAG_NULL, "", Java)
+	if err != nil {
+		t.Fatalf("ReadNBTDataBytes: %v", err)
+	}
+
+	if got.Type != TAG_Compound {
+		t.Fatalf("got.Type = %s, want TAG_Compound", got.Type)
+	}
+	fields := got.Data.([]NBT)
+	if len(fields) != 1 || fields[0].Name != "Name" || fields[0].Data.(string) != "Steve" {
+		t.Fatalf("unexpected decoded fields: %+v", fields)
+	}
+}
+
+// TestReadNBTDataConcurrent exercises the scratch8Pool under concurrent ReadNBTData calls (run with -race), the
+// streaming-multiple-chunks-in-parallel use case the pooling was added for; it guards against readScratch handing
+// its pooled array back to the pool before the caller has decoded out of it
+func TestReadNBTDataConcurrent(t *testing.T) {
+	src := &NBT{Type: TAG_Compound, Data: []NBT{
+		{Type: TAG_Short, Name: "Short", Data: int16(-1234)},
+		{Type: TAG_Int, Name: "Int", Data: int32(-70000)},
+		{Type: TAG_Long, Name: "Long", Data: int64(-8019191920289085320)},
+		{Type: TAG_Float, Name: "Float", Data: float32(3.5)},
+		{Type: TAG_Double, Name: "Double", Data: 64.25},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteNBTData(&buf, src, Java); err != nil {
+		t.Fatalf("WriteNBTData: %v", err)
+	}
+	data := buf.Bytes()
+
+	want, err := ReadNBTData(bytes.NewReader(data), TAG_NULL, "", Java)
+	if err != nil {
+		t.Fatalf("ReadNBTData: %v", err)
+	}
+
+	const goroutines = 32
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				got, err := ReadNBTData(bytes.NewReader(data), TAG_NULL, "", Java)
+				if err != nil {
+					t.Errorf("ReadNBTData: %v", err)
+					return
+				}
+				if !reflect.DeepEqual(want, got) {
+					t.Errorf("concurrent decode mismatch:\n want %+v\n got  %+v", want, got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,419 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Path : dotted-name / bracketed-index queries over an NBT tree  ///////////////////////////////////////////////////////////////
+//
+// drilling into a parsed Chunk today means manually type-asserting Data.([]NBT) at every compound and list level; Path
+// collapses that into a single dotted-and-bracketed string, e.g. "Level.Sections[3].Palette[0].Name", so that generic
+// tools (chunk editors, migration scripts) can be built against the shape of a tree without knowing its schema up front
+//
+// a path is a flat sequence of steps : a compound-key step ("Level"), a list-index step ("[3]"), or a list fan-out step
+// ("[*]"), read left to right, e.g. "Foo[*].Bar" means "for every element of list Foo, its Bar field"
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type pathStepKind int
+
+const (
+	pathKey pathStepKind = iota
+	pathIndex
+	pathWildcard
+)
+
+type pathStep struct {
+	Kind  pathStepKind
+	Name  string
+	Index int
+}
+
+// Path is the parsed form of a dotted-name / bracketed-index query string; Get, Set, Delete, and Walk all parse their
+// string argument into a Path before walking the tree, but Path is exported so callers who evaluate the same path
+// repeatedly can parse it once via ParsePath and reuse it
+//
+type Path []pathStep
+
+// String reconstructs the dotted-and-bracketed path text that produced p
+func (p Path) String() string {
+	var b strings.Builder
+	for _, step := range p {
+		switch step.Kind {
+		case pathKey:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(step.Name)
+		case pathIndex:
+			fmt.Fprintf(&b, "[%d]", step.Index)
+		case pathWildcard:
+			b.WriteString("[*]")
+		}
+	}
+	return b.String()
+}
+
+// ParsePath parses s, a dotted compound-name path with optional bracketed list indices or "[*]" fan-outs, e.g.
+// "Level.Sections[3].Palette[0].Name" or "Players[*].Inventory[0].id"
+//
+func ParsePath(s string) (Path, error) {
+	if s == "" {
+		return nil, fmt.Errorf("nbt: ParsePath: empty path")
+	}
+
+	var path Path
+	for _, tok := range strings.Split(s, ".") {
+		if tok == "" {
+			return nil, fmt.Errorf("nbt: ParsePath: %q: empty path segment", s)
+		}
+
+		name := tok
+		rest := ""
+		if i := strings.IndexByte(tok, '['); i >= 0 {
+			name, rest = tok[:i], tok[i:]
+		}
+		if name == "" && len(path) == 0 {
+			return nil, fmt.Errorf("nbt: ParsePath: %q: path must start with a compound key", s)
+		}
+		if name != "" {
+			path = append(path, pathStep{Kind: pathKey, Name: name})
+		}
+
+		for len(rest) > 0 {
+			if rest[0] != '[' {
+				return nil, fmt.Errorf("nbt: ParsePath: %q: expected '[' in segment %q", s, tok)
+			}
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("nbt: ParsePath: %q: unterminated '[' in segment %q", s, tok)
+			}
+
+			inner := rest[1:end]
+			switch inner {
+			case "*":
+				path = append(path, pathStep{Kind: pathWildcard})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("nbt: ParsePath: %q: invalid index %q in segment %q", s, inner, tok)
+				}
+				path = append(path, pathStep{Kind: pathIndex, Index: idx})
+			}
+
+			rest = rest[end+1:]
+		}
+	}
+
+	return path, nil
+}
+
+// hasWildcard reports whether p contains a "[*]" fan-out step
+func (p Path) hasWildcard() bool {
+	for _, step := range p {
+		if step.Kind == pathWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve walks path from n, returning a pointer to every matching node; the pointers alias the real Data slices in the
+// tree (slices share their backing array across copies), so mutating a returned *NBT mutates the tree itself
+//
+// when create is true, a missing compound key is added : as a placeholder TAG_End leaf if it is the final step, or as
+// an empty TAG_Compound if any further step follows (even an index or "[*]" step, which then fails on it); missing list indices and "[*]" over a non-list are never created,
+// since there is no sane default list to create one into
+//
+func (n *NBT) resolve(path Path, create bool) ([]*NBT, error) {
+	cur := []*NBT{n}
+
+	for i, step := range path {
+		last := i == len(path)-1
+
+		var next []*NBT
+		for _, node := range cur {
+			switch step.Kind {
+			case pathKey:
+				if node.Type != TAG_Compound {
+					return nil, fmt.Errorf("nbt: path %q: %q is %s, not TAG_Compound", path, step.Name, NBTTAGName[node.Type])
+				}
+
+				elems := node.Data.([]NBT)
+				idx := indexOfName(elems, step.Name)
+				if idx < 0 {
+					if !create {
+						return nil, fmt.Errorf("nbt: path %q: key %q not found", path, step.Name)
+					}
+
+					child := NBT{Name: step.Name, Type: TAG_End}
+					if !last {
+						child = NBT{Name: step.Name, Type: TAG_Compound, Data: []NBT{}}
+					}
+					elems = append(elems, child)
+					node.Data = elems
+					node.Size = uint32(len(elems))
+					idx = len(elems) - 1
+				}
+				next = append(next, &elems[idx])
+
+			case pathIndex:
+				if node.Type != TAG_List {
+					return nil, fmt.Errorf("nbt: path %q: expected TAG_List for [%d], got %s", path, step.Index, NBTTAGName[node.Type])
+				}
+				elems := node.Data.([]NBT)
+				if step.Index < 0 || step.Index >= len(elems) {
+					return nil, fmt.Errorf("nbt: path %q: index [%d] out of range (len %d)", path, step.Index, len(elems))
+				}
+				next = append(next, &elems[step.Index])
+
+			case pathWildcard:
+				if node.Type != TAG_List {
+					return nil, fmt.Errorf("nbt: path %q: [*] requires TAG_List, got %s", path, NBTTAGName[node.Type])
+				}
+				elems := node.Data.([]NBT)
+				for j := range elems {
+					next = append(next, &elems[j])
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			return nil, fmt.Errorf("nbt: path %q: no matching elements", path)
+		}
+		cur = next
+	}
+
+	return cur, nil
+}
+
+func indexOfName(elems []NBT, name string) int {
+	for i := range elems {
+		if elems[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Get / Set / Delete / Walk  ////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Get evaluates path against n and returns the node(s) it names : a *NBT for an ordinary path, or a []*NBT when path
+// contains a "[*]" fan-out step; the returned pointers alias the tree, so callers may mutate them directly instead of
+// going through Set
+//
+func (n *NBT) Get(path string) (interface{}, error) {
+	p, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := n.resolve(p, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.hasWildcard() {
+		return results, nil
+	}
+	return results[0], nil
+}
+
+// Set evaluates path against n and replaces the node(s) it names with value; value may be an *NBT or NBT (used as-is,
+// keeping the original Name), or any value Marshal can encode (e.g. an int32, a string, a struct), which is converted
+// the same way Marshal converts struct fields; a missing compound key is created, following the create rule described
+// on (*NBT).resolve
+//
+func (n *NBT) Set(path string, value interface{}) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+
+	targets, err := n.resolve(p, true)
+	if err != nil {
+		return err
+	}
+
+	var payload *NBT
+	switch v := value.(type) {
+	case *NBT:
+		payload = v
+	case NBT:
+		payload = &v
+	default:
+		payload, err = marshalValue(reflect.ValueOf(value), "")
+		if err != nil {
+			return fmt.Errorf("nbt: Set: %q: %w", path, err)
+		}
+	}
+
+	for _, t := range targets {
+		name := t.Name
+		*t = *payload
+		t.Name = name
+	}
+
+	return nil
+}
+
+// Delete evaluates path against n and removes the node(s) it names from their parent compound or list; deleting a
+// "[*]" step empties the parent list
+//
+func (n *NBT) Delete(path string) error {
+	p, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	if len(p) == 0 {
+		return fmt.Errorf("nbt: Delete: empty path")
+	}
+
+	last := p[len(p)-1]
+
+	parents, err := n.resolve(p[:len(p)-1], false)
+	if err != nil {
+		return err
+	}
+
+	for _, parent := range parents {
+		switch last.Kind {
+		case pathKey:
+			if parent.Type != TAG_Compound {
+				return fmt.Errorf("nbt: Delete: %q: %q is %s, not TAG_Compound", path, last.Name, NBTTAGName[parent.Type])
+			}
+			elems := parent.Data.([]NBT)
+			idx := indexOfName(elems, last.Name)
+			if idx < 0 {
+				return fmt.Errorf("nbt: Delete: %q: key %q not found", path, last.Name)
+			}
+			elems = append(elems[:idx], elems[idx+1:]...)
+			parent.Data = elems
+			parent.Size = uint32(len(elems))
+
+		case pathIndex:
+			if parent.Type != TAG_List {
+				return fmt.Errorf("nbt: Delete: %q: expected TAG_List for [%d], got %s", path, last.Index, NBTTAGName[parent.Type])
+			}
+			elems := parent.Data.([]NBT)
+			if last.Index < 0 || last.Index >= len(elems) {
+				return fmt.Errorf("nbt: Delete: %q: index [%d] out of range (len %d)", path, last.Index, len(elems))
+			}
+			elems = append(elems[:last.Index], elems[last.Index+1:]...)
+			parent.Data = elems
+			parent.Size = uint32(len(elems))
+
+		case pathWildcard:
+			if parent.Type != TAG_List {
+				return fmt.Errorf("nbt: Delete: %q: [*] requires TAG_List, got %s", path, NBTTAGName[parent.Type])
+			}
+			parent.Data = []NBT{}
+			parent.Size = 0
+		}
+	}
+
+	return nil
+}
+
+// Walk calls fn once for every node in the tree rooted at n, depth-first, passing each node's path (using the same
+// dotted / bracketed notation ParsePath accepts; the root itself is visited with path ""); Walk stops and returns the
+// first error fn returns
+//
+func (n *NBT) Walk(fn func(path string, node *NBT) error) error {
+	return walkNode("", n, fn)
+}
+
+func walkNode(path string, n *NBT, fn func(string, *NBT) error) error {
+	if err := fn(path, n); err != nil {
+		return err
+	}
+
+	switch n.Type {
+	case TAG_Compound:
+		elems := n.Data.([]NBT)
+		for i := range elems {
+			childPath := elems[i].Name
+			if path != "" {
+				childPath = path + "." + elems[i].Name
+			}
+			if err := walkNode(childPath, &elems[i], fn); err != nil {
+				return err
+			}
+		}
+
+	case TAG_List:
+		elems := n.Data.([]NBT)
+		for i := range elems {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := walkNode(childPath, &elems[i], fn); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  typed convenience getters  ////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// these wrap Get for the common case of expecting one specific TAG at path; they report (zero value, false)
+// instead of panicking on a missing path or a type mismatch, replacing the unchecked Data.(T) assertions this package
+// otherwise requires at every call site
+
+func (n *NBT) getNode(path string) (*NBT, bool) {
+	v, err := n.Get(path)
+	if err != nil {
+		return nil, false
+	}
+	node, ok := v.(*NBT)
+	return node, ok
+}
+
+// GetInt returns the TAG_Int at path
+func (n *NBT) GetInt(path string) (int32, bool) {
+	node, ok := n.getNode(path)
+	if !ok || node.Type != TAG_Int {
+		return 0, false
+	}
+	return node.Data.(int32), true
+}
+
+// GetLong returns the TAG_Long at path
+func (n *NBT) GetLong(path string) (int64, bool) {
+	node, ok := n.getNode(path)
+	if !ok || node.Type != TAG_Long {
+		return 0, false
+	}
+	return node.Data.(int64), true
+}
+
+// GetString returns the TAG_String at path
+func (n *NBT) GetString(path string) (string, bool) {
+	node, ok := n.getNode(path)
+	if !ok || node.Type != TAG_String {
+		return "", false
+	}
+	return node.Data.(string), true
+}
+
+// GetCompound returns the TAG_Compound at path
+func (n *NBT) GetCompound(path string) (*NBT, bool) {
+	node, ok := n.getNode(path)
+	if !ok || node.Type != TAG_Compound {
+		return nil, false
+	}
+	return node, true
+}
+
+// GetList returns the TAG_List at path
+func (n *NBT) GetList(path string) (*NBT, bool) {
+	node, ok := n.getNode(path)
+	if !ok || node.Type != TAG_List {
+		return nil, false
+	}
+	return node, true
+}
@@ -0,0 +1,345 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Path / Get / Set / Delete / Walk tests  ///////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"errors"
+	"testing"
+)
+
+var errStop = errors.New("path_test: stop")
+
+// samplePathTree builds a small tree with a nested compound, a list of compounds, and a list of scalars, covering the
+// shapes Path's key / index / wildcard steps need to walk
+func samplePathTree() *NBT {
+	return &NBT{
+		Type: TAG_Compound,
+		Data: []NBT{
+			{Type: TAG_Compound, Name: "Level", Data: []NBT{
+				{Type: TAG_String, Name: "Name", Data: "Steve"},
+				{Type: TAG_List, List: TAG_Compound, Name: "Players", Data: []NBT{
+					{Type: TAG_Compound, Data: []NBT{
+						{Type: TAG_String, Name: "id", Data: "p1"},
+					}},
+					{Type: TAG_Compound, Data: []NBT{
+						{Type: TAG_String, Name: "id", Data: "p2"},
+					}},
+				}},
+			}},
+			{Type: TAG_List, List: TAG_Int, Name: "Scores", Data: []NBT{
+				{Type: TAG_Int, Data: int32(10)},
+				{Type: TAG_Int, Data: int32(20)},
+			}},
+		},
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	cases := []struct {
+		in      string
+		wantErr bool
+	}{
+		{"Level.Sections[3].Palette[0].Name", false},
+		{"Players[*].Inventory[0].id", false},
+		{"Foo", false},
+		{"", true},
+		{"Foo..Bar", true},
+		{"[0]", true},
+		{"Foo[", true},
+		{"Foo[x]", true},
+	}
+
+	for _, c := range cases {
+		_, err := ParsePath(c.in)
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParsePath(%q) error = %v, wantErr %v", c.in, err, c.wantErr)
+		}
+	}
+}
+
+func TestPathString(t *testing.T) {
+	p, err := ParsePath("Level.Players[*].Inventory[0].id")
+	if err != nil {
+		t.Fatalf("ParsePath: %v", err)
+	}
+	if got, want := p.String(), "Level.Players[*].Inventory[0].id"; got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestGetScalar(t *testing.T) {
+	tree := samplePathTree()
+
+	v, err := tree.Get("Level.Name")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	node, ok := v.(*NBT)
+	if !ok || node.Data.(string) != "Steve" {
+		t.Fatalf("Get(Level.Name) = %+v, want TAG_String \"Steve\"", v)
+	}
+}
+
+func TestGetIndex(t *testing.T) {
+	tree := samplePathTree()
+
+	v, err := tree.Get("Scores[1]")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	node := v.(*NBT)
+	if node.Data.(int32) != 20 {
+		t.Fatalf("Get(Scores[1]) = %v, want 20", node.Data)
+	}
+}
+
+func TestGetWildcard(t *testing.T) {
+	tree := samplePathTree()
+
+	v, err := tree.Get("Level.Players[*].id")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	nodes, ok := v.([]*NBT)
+	if !ok {
+		t.Fatalf("Get([*]) returned %T, want []*NBT", v)
+	}
+	if len(nodes) != 2 || nodes[0].Data.(string) != "p1" || nodes[1].Data.(string) != "p2" {
+		t.Fatalf("Get([*]) = %+v, want [p1 p2]", nodes)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	tree := samplePathTree()
+
+	cases := []string{
+		"Level.Missing",           // key not found
+		"Level.Name.Missing",      // indexing into a non-compound
+		"Scores[5]",               // index out of range
+		"Scores[0].Missing",       // key step on a non-compound (TAG_Int)
+		"Level[0]",                // index step on a non-list (TAG_Compound)
+		"Level.Name[*]",           // wildcard on a non-list
+	}
+	for _, path := range cases {
+		if _, err := tree.Get(path); err == nil {
+			t.Errorf("Get(%q) = nil error, want error", path)
+		}
+	}
+}
+
+func TestSetExistingKey(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Set("Level.Name", "Alex"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, ok := tree.GetString("Level.Name")
+	if !ok || got != "Alex" {
+		t.Fatalf("GetString(Level.Name) = %q, %v, want \"Alex\", true", got, ok)
+	}
+}
+
+func TestSetCreatesMissingKey(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Set("Level.Difficulty", int32(2)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, ok := tree.GetInt("Level.Difficulty")
+	if !ok || got != 2 {
+		t.Fatalf("GetInt(Level.Difficulty) = %v, %v, want 2, true", got, ok)
+	}
+}
+
+func TestSetCreatesNestedMissingCompounds(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Set("Level.Options.Seed", int64(42)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, ok := tree.GetLong("Level.Options.Seed")
+	if !ok || got != 42 {
+		t.Fatalf("GetLong(Level.Options.Seed) = %v, %v, want 42, true", got, ok)
+	}
+}
+
+func TestSetKeepsOriginalName(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Set("Level.Name", NBT{Type: TAG_String, Name: "ignored", Data: "Herobrine"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	node, ok := tree.getNode("Level.Name")
+	if !ok {
+		t.Fatalf("getNode(Level.Name) not found after Set")
+	}
+	if node.Name != "Name" {
+		t.Fatalf("Set overwrote Name field: got %q, want \"Name\" (the original key, not the payload's)", node.Name)
+	}
+	if node.Data.(string) != "Herobrine" {
+		t.Fatalf("Set did not apply new value: got %v", node.Data)
+	}
+}
+
+func TestSetWildcard(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Set("Scores[*]", int32(0)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	v, err := tree.Get("Scores[*]")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	for _, node := range v.([]*NBT) {
+		if node.Data.(int32) != 0 {
+			t.Fatalf("Set([*]) left %v, want every element reset to 0", node.Data)
+		}
+	}
+}
+
+func TestSetIndexOutOfRange(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Set("Scores[9]", int32(1)); err == nil {
+		t.Fatalf("Set(Scores[9]) = nil error, want error (Set never creates missing list indices)")
+	}
+}
+
+func TestDeleteKey(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Delete("Level.Name"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := tree.Get("Level.Name"); err == nil {
+		t.Fatalf("Get(Level.Name) succeeded after Delete, want error")
+	}
+}
+
+func TestDeleteIndex(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Delete("Scores[0]"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	got, ok := tree.GetList("Scores")
+	if !ok {
+		t.Fatalf("GetList(Scores) not found after Delete")
+	}
+	elems := got.Data.([]NBT)
+	if len(elems) != 1 || elems[0].Data.(int32) != 20 {
+		t.Fatalf("Scores after Delete[0] = %+v, want [20]", elems)
+	}
+}
+
+func TestDeleteWildcard(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Delete("Scores[*]"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	got, ok := tree.GetList("Scores")
+	if !ok {
+		t.Fatalf("GetList(Scores) not found after Delete")
+	}
+	if elems := got.Data.([]NBT); len(elems) != 0 {
+		t.Fatalf("Scores after Delete[*] = %+v, want empty", elems)
+	}
+}
+
+func TestDeleteOutOfRange(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Delete("Scores[9]"); err == nil {
+		t.Fatalf("Delete(Scores[9]) = nil error, want error")
+	}
+}
+
+func TestDeleteMissingKey(t *testing.T) {
+	tree := samplePathTree()
+
+	if err := tree.Delete("Level.Missing"); err == nil {
+		t.Fatalf("Delete(Level.Missing) = nil error, want error")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tree := samplePathTree()
+
+	var paths []string
+	if err := tree.Walk(func(path string, node *NBT) error {
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	want := []string{
+		"",
+		"Level",
+		"Level.Name",
+		"Level.Players",
+		"Level.Players[0]",
+		"Level.Players[0].id",
+		"Level.Players[1]",
+		"Level.Players[1].id",
+		"Scores",
+		"Scores[0]",
+		"Scores[1]",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("Walk visited %d nodes, want %d\ngot:  %v\nwant: %v", len(paths), len(want), paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("Walk path[%d] = %q, want %q", i, paths[i], want[i])
+		}
+	}
+}
+
+func TestWalkStopsOnError(t *testing.T) {
+	tree := samplePathTree()
+
+	stopErr := errStop
+	visited := 0
+	err := tree.Walk(func(path string, node *NBT) error {
+		visited++
+		if path == "Level.Name" {
+			return stopErr
+		}
+		return nil
+	})
+
+	if err != stopErr {
+		t.Fatalf("Walk error = %v, want the error returned by fn", err)
+	}
+	if visited != 3 {
+		t.Fatalf("Walk visited %d nodes before stopping, want 3 (root, Level, Level.Name)", visited)
+	}
+}
+
+func TestTypedGettersMismatch(t *testing.T) {
+	tree := samplePathTree()
+
+	if _, ok := tree.GetInt("Level.Name"); ok {
+		t.Fatalf("GetInt(Level.Name) = true, want false (node is TAG_String)")
+	}
+	if _, ok := tree.GetString("Level.Missing"); ok {
+		t.Fatalf("GetString(Level.Missing) = true, want false (missing path)")
+	}
+	if _, ok := tree.GetCompound("Level.Name"); ok {
+		t.Fatalf("GetCompound(Level.Name) = true, want false (node is TAG_String)")
+	}
+	if _, ok := tree.GetList("Level.Name"); ok {
+		t.Fatalf("GetList(Level.Name) = true, want false (node is TAG_String)")
+	}
+}
@@ -0,0 +1,400 @@
+// Package region reads and writes Minecraft Java Edition's Anvil region files (.mca, and the older .mcr), the
+// container format that packs up to 1024 chunks' worth of NBT data into one file
+package region
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Anvil region file format  ////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// a region file covers a 32x32 grid of chunks; the first 8 KiB is a fixed header made up of two 4 KiB tables, each holding
+// one entry per chunk, indexed by ((x&31) + (z&31)*32) :
+//
+//   - the offset table : a big-endian uint32 per chunk, packed as (sectorOffset<<8 | sectorCount); a zero entry means the
+//     chunk has not been generated yet
+//   - the timestamp table : a big-endian uint32 per chunk, the Unix time the chunk was last written
+//
+// after the header, chunk payloads are stored in 4 KiB-aligned "sectors"; each payload starts with a big-endian uint32
+// byte-length, followed by a single compression-type byte (1 = gzip, 2 = zlib, 3 = uncompressed; the high bit set means
+// the payload actually lives in an external ".mcc" file and this sector only carries a marker), and then length-1 bytes
+// that decompress into a single top-level TAG_Compound
+//
+// reference : https://minecraft.wiki/w/Region_file_format
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/landru27/nbt"
+)
+
+const (
+	sectorSize    = 4096
+	headerSectors = 2
+	chunksPerSide = 32
+)
+
+// CompressionType identifies how a chunk's payload bytes are compressed on disk
+type CompressionType byte
+
+const (
+	CompressionGZip         CompressionType = 1
+	CompressionZlib         CompressionType = 2
+	CompressionUncompressed CompressionType = 3
+
+	externalFileFlag = 0x80
+)
+
+// Region is an open Anvil region file; it owns the backing *os.File and the in-memory copy of the two header tables
+type Region struct {
+	file *os.File
+
+	offsets    [chunksPerSide * chunksPerSide]uint32
+	timestamps [chunksPerSide * chunksPerSide]uint32
+
+	// RegionX / RegionZ are the region's own coordinates, parsed from a "r.<x>.<z>.mca" style filename; they are
+	// needed to build the absolute chunk coordinates that external ".mcc" files are named after
+	RegionX int
+	RegionZ int
+
+	// Compression is used for WriteChunk; it defaults to CompressionZlib, matching vanilla Minecraft
+	Compression CompressionType
+}
+
+var regionFileName = regexp.MustCompile(`^r\.(-?\d+)\.(-?\d+)\.mc[ar]$`)
+
+// Open opens the region file at path for reading and writing, creating it (with an empty header) if it does not
+// already exist
+func Open(path string) (*Region, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Region{file: file, Compression: CompressionZlib}
+
+	if m := regionFileName.FindStringSubmatch(filepath.Base(path)); m != nil {
+		r.RegionX, _ = strconv.Atoi(m[1])
+		r.RegionZ, _ = strconv.Atoi(m[2])
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.Size() == 0 {
+		if err := r.writeHeader(); err != nil {
+			file.Close()
+			return nil, err
+		}
+	} else if err := r.readHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Close flushes nothing further (writes are already committed as they happen) and closes the backing file
+func (r *Region) Close() error {
+	return r.file.Close()
+}
+
+func chunkIndex(x, z int) int {
+	return (x & (chunksPerSide - 1)) + (z&(chunksPerSide-1))*chunksPerSide
+}
+
+func (r *Region) readHeader() error {
+	raw := make([]byte, headerSectors*sectorSize)
+	if _, err := io.ReadFull(io.NewSectionReader(r.file, 0, int64(len(raw))), raw); err != nil {
+		return fmt.Errorf("region: readHeader: %w", err)
+	}
+
+	for i := 0; i < chunksPerSide*chunksPerSide; i++ {
+		r.offsets[i] = beUint32(raw[i*4:])
+		r.timestamps[i] = beUint32(raw[sectorSize+i*4:])
+	}
+
+	return nil
+}
+
+func (r *Region) writeHeader() error {
+	raw := make([]byte, headerSectors*sectorSize)
+
+	for i := 0; i < chunksPerSide*chunksPerSide; i++ {
+		putBeUint32(raw[i*4:], r.offsets[i])
+		putBeUint32(raw[sectorSize+i*4:], r.timestamps[i])
+	}
+
+	_, err := r.file.WriteAt(raw, 0)
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  reading chunks  ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// HasChunk reports whether the given chunk (in region-local 0..31 coordinates) has been generated
+func (r *Region) HasChunk(x, z int) bool {
+	return r.offsets[chunkIndex(x, z)] != 0
+}
+
+// ReadChunk reads and decompresses the chunk at (x, z) (region-local 0..31 coordinates) and parses it into an NBT tree
+func (r *Region) ReadChunk(x, z int) (*nbt.NBT, error) {
+	entry := r.offsets[chunkIndex(x, z)]
+	if entry == 0 {
+		return nil, fmt.Errorf("region: ReadChunk(%d,%d): chunk not present", x, z)
+	}
+
+	sectorOffset := int64(entry >> 8)
+	sectorCount := int64(entry & 0xFF)
+
+	header := make([]byte, 5)
+	if _, err := r.file.ReadAt(header, sectorOffset*sectorSize); err != nil {
+		return nil, fmt.Errorf("region: ReadChunk(%d,%d): %w", x, z, err)
+	}
+
+	length := beUint32(header)
+	compression := header[4]
+
+	var payload []byte
+	if compression&externalFileFlag != 0 {
+		data, err := r.readExternal(x, z)
+		if err != nil {
+			return nil, err
+		}
+		payload = data
+	} else {
+		payload = make([]byte, int64(length)-1)
+		if _, err := r.file.ReadAt(payload, sectorOffset*sectorSize+5); err != nil {
+			return nil, fmt.Errorf("region: ReadChunk(%d,%d): %w", x, z, err)
+		}
+	}
+	_ = sectorCount
+
+	decompressed, err := decompress(CompressionType(compression&^externalFileFlag), payload)
+	if err != nil {
+		return nil, fmt.Errorf("region: ReadChunk(%d,%d): %w", x, z, err)
+	}
+
+	root, err := nbt.ReadNBTData(bytes.NewReader(decompressed), nbt.TAG_NULL, "", nbt.Java)
+	if err != nil {
+		return nil, fmt.Errorf("region: ReadChunk(%d,%d): %w", x, z, err)
+	}
+
+	return &root, nil
+}
+
+func (r *Region) mccPath(x, z int) string {
+	absX := r.RegionX*chunksPerSide + (x & (chunksPerSide - 1))
+	absZ := r.RegionZ*chunksPerSide + (z & (chunksPerSide - 1))
+	return filepath.Join(filepath.Dir(r.file.Name()), fmt.Sprintf("c.%d.%d.mcc", absX, absZ))
+}
+
+func (r *Region) readExternal(x, z int) ([]byte, error) {
+	return os.ReadFile(r.mccPath(x, z))
+}
+
+func decompress(kind CompressionType, payload []byte) ([]byte, error) {
+	switch kind {
+	case CompressionGZip:
+		gr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		return io.ReadAll(gr)
+
+	case CompressionZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(zr)
+
+	case CompressionUncompressed:
+		return payload, nil
+	}
+
+	return nil, fmt.Errorf("region: unknown compression type %d", kind)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  writing chunks  ///////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// WriteChunk compresses root and stores it as the chunk at (x, z) (region-local 0..31 coordinates), allocating sectors,
+// padding the payload to a 4 KiB boundary, and updating both the offset table and the timestamp table; the updated
+// header is flushed immediately, so the Region is always self-consistent on disk. The offset table's sector-count
+// byte can address at most 255 sectors (~1020 KiB of compressed payload); a chunk that doesn't fit falls back to an
+// external ".mcc" file, the same fallback ReadChunk / readExternal already know how to consume, so WriteChunk never
+// has to reject an oversized chunk outright
+func (r *Region) WriteChunk(x, z int, root *nbt.NBT) error {
+	var raw bytes.Buffer
+	if err := nbt.WriteNBTData(&raw, root, nbt.Java); err != nil {
+		return fmt.Errorf("region: WriteChunk(%d,%d): %w", x, z, err)
+	}
+
+	compressed, err := compress(r.Compression, raw.Bytes())
+	if err != nil {
+		return fmt.Errorf("region: WriteChunk(%d,%d): %w", x, z, err)
+	}
+
+	payload := make([]byte, 5+len(compressed))
+	putBeUint32(payload, uint32(len(compressed)+1))
+	payload[4] = byte(r.Compression)
+	copy(payload[5:], compressed)
+
+	sectorsNeeded := (len(payload) + sectorSize - 1) / sectorSize
+	if sectorsNeeded > 0xFF {
+		// doesn't fit in the sector-count byte; spill the compressed payload to an external ".mcc" file and leave
+		// only a 1-sector marker (length 1, compression type with the external-file flag set, no payload bytes)
+		// in the region file itself, mirroring how readExternal expects to find it
+		if err := os.WriteFile(r.mccPath(x, z), compressed, 0644); err != nil {
+			return fmt.Errorf("region: WriteChunk(%d,%d): %w", x, z, err)
+		}
+
+		payload = make([]byte, 5)
+		putBeUint32(payload, 1)
+		payload[4] = byte(r.Compression) | externalFileFlag
+		sectorsNeeded = 1
+	}
+
+	idx := chunkIndex(x, z)
+	sectorOffset, err := r.allocateSectors(idx, sectorsNeeded)
+	if err != nil {
+		return fmt.Errorf("region: WriteChunk(%d,%d): %w", x, z, err)
+	}
+
+	padded := make([]byte, sectorsNeeded*sectorSize)
+	copy(padded, payload)
+	if _, err := r.file.WriteAt(padded, sectorOffset*sectorSize); err != nil {
+		return fmt.Errorf("region: WriteChunk(%d,%d): %w", x, z, err)
+	}
+
+	r.offsets[idx] = uint32(sectorOffset)<<8 | uint32(sectorsNeeded)
+	r.timestamps[idx] = uint32(time.Now().Unix())
+
+	return r.writeHeader()
+}
+
+// allocateSectors finds (or extends the file to make) sectorsNeeded contiguous free sectors for chunk idx, frees the
+// chunk's previous allocation (if any), and returns the sector index of the new allocation
+func (r *Region) allocateSectors(idx int, sectorsNeeded int) (int64, error) {
+	used := make(map[int64]bool)
+	for i, entry := range r.offsets {
+		if entry == 0 || i == idx {
+			continue
+		}
+		offset := int64(entry >> 8)
+		count := int64(entry & 0xFF)
+		for s := offset; s < offset+count; s++ {
+			used[s] = true
+		}
+	}
+
+	info, err := r.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	fileSectors := info.Size() / sectorSize
+
+	var candidate int64
+	for candidate = headerSectors; candidate+int64(sectorsNeeded) <= fileSectors; candidate++ {
+		free := true
+		for s := candidate; s < candidate+int64(sectorsNeeded); s++ {
+			if used[s] {
+				free = false
+				break
+			}
+		}
+		if free {
+			return candidate, nil
+		}
+	}
+
+	return fileSectors, nil
+}
+
+func compress(kind CompressionType, raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch kind {
+	case CompressionGZip:
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+	case CompressionZlib:
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+	case CompressionUncompressed:
+		buf.Write(raw)
+
+	default:
+		return nil, fmt.Errorf("region: unknown compression type %d", kind)
+	}
+
+	return buf.Bytes(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  iteration  ////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ChunkPos is a region-local chunk coordinate pair, in the 0..31 range on each axis
+type ChunkPos struct {
+	X, Z int
+}
+
+// Chunks returns the coordinates of every chunk currently present in the region file, ordered by chunk index
+func (r *Region) Chunks() []ChunkPos {
+	var positions []ChunkPos
+
+	for i, entry := range r.offsets {
+		if entry == 0 {
+			continue
+		}
+		positions = append(positions, ChunkPos{X: i % chunksPerSide, Z: i / chunksPerSide})
+	}
+
+	sort.Slice(positions, func(a, b int) bool {
+		if positions[a].Z != positions[b].Z {
+			return positions[a].Z < positions[b].Z
+		}
+		return positions[a].X < positions[b].X
+	})
+
+	return positions
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  small big-endian helpers (avoid pulling in encoding/binary for four bytes at a time)  ////////////////////////////////////////
+
+func beUint32(b []byte) uint32 {
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+func putBeUint32(b []byte, v uint32) {
+	b[0] = byte(v >> 24)
+	b[1] = byte(v >> 16)
+	b[2] = byte(v >> 8)
+	b[3] = byte(v)
+}
@@ -0,0 +1,248 @@
+package region
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Region file round-trip tests  /////////////////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/landru27/nbt"
+)
+
+// chunkNBT builds a small TAG_Compound whose single TAG_Byte_Array payload is n bytes long, letting tests dial up the
+// compressed size of a "chunk" without hand-building a realistic Minecraft chunk tree
+func chunkNBT(n int) *nbt.NBT {
+	data := make([]byte, n)
+	state := uint32(0x2545F491)
+	for i := range data {
+		// xorshift32: avoids a short repeating pattern so zlib can't compress large fillers down to ~nothing, which
+		// would defeat tests that rely on a chunk needing a specific number of sectors
+		state ^= state << 13
+		state ^= state >> 17
+		state ^= state << 5
+		data[i] = byte(state)
+	}
+	return &nbt.NBT{Type: nbt.TAG_Compound, Size: 1, Data: []nbt.NBT{
+		{Type: nbt.TAG_Byte_Array, Name: "Filler", Size: uint32(n), Data: data},
+	}}
+}
+
+// mustOpen opens a fresh region file under t.TempDir() named so RegionX/RegionZ come out as (0, 0)
+func mustOpen(t *testing.T) *Region {
+	t.Helper()
+	r, err := Open(filepath.Join(t.TempDir(), "r.0.0.mca"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// TestWriteReadRoundTrip writes chunks of varying sizes (including one forcing file growth) to a handful of
+// coordinates, closes and reopens the file, and checks ReadChunk reconstructs exactly what was written
+func TestWriteReadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "r.0.0.mca")
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	type chunk struct {
+		x, z int
+		want *nbt.NBT
+	}
+	chunks := []chunk{
+		{0, 0, chunkNBT(10)},
+		{1, 0, chunkNBT(0)},
+		{31, 31, chunkNBT(20000)}, // large enough to need several sectors
+		{5, 7, chunkNBT(500)},
+	}
+
+	for _, c := range chunks {
+		if err := r.WriteChunk(c.x, c.z, c.want); err != nil {
+			t.Fatalf("WriteChunk(%d,%d): %v", c.x, c.z, err)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r2, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer r2.Close()
+
+	for _, c := range chunks {
+		if !r2.HasChunk(c.x, c.z) {
+			t.Fatalf("HasChunk(%d,%d) = false, want true", c.x, c.z)
+		}
+		got, err := r2.ReadChunk(c.x, c.z)
+		if err != nil {
+			t.Fatalf("ReadChunk(%d,%d): %v", c.x, c.z, err)
+		}
+		if !reflect.DeepEqual(*c.want, *got) {
+			t.Fatalf("ReadChunk(%d,%d) round-trip mismatch:\n want %+v\n got  %+v", c.x, c.z, *c.want, *got)
+		}
+	}
+
+	if r2.HasChunk(2, 2) {
+		t.Fatalf("HasChunk(2,2) = true, want false (never written)")
+	}
+	if _, err := r2.ReadChunk(2, 2); err == nil {
+		t.Fatalf("ReadChunk(2,2) = nil error, want error for an absent chunk")
+	}
+}
+
+// TestHeaderBytesAfterWrite checks the on-disk offset-table entry for a written chunk matches the sector math
+// WriteChunk is documented to perform: sectorOffset<<8 | sectorCount, pointing past the 2-sector header
+func TestHeaderBytesAfterWrite(t *testing.T) {
+	r := mustOpen(t)
+
+	if err := r.WriteChunk(3, 4, chunkNBT(10)); err != nil {
+		t.Fatalf("WriteChunk: %v", err)
+	}
+
+	entry := r.offsets[chunkIndex(3, 4)]
+	sectorOffset := entry >> 8
+	sectorCount := entry & 0xFF
+
+	if sectorOffset != headerSectors {
+		t.Fatalf("sectorOffset = %d, want %d (first sector after the header)", sectorOffset, headerSectors)
+	}
+	if sectorCount != 1 {
+		t.Fatalf("sectorCount = %d, want 1 for a small chunk", sectorCount)
+	}
+
+	raw := make([]byte, 4)
+	if _, err := r.file.ReadAt(raw, int64(chunkIndex(3, 4))*4); err != nil {
+		t.Fatalf("ReadAt offset table: %v", err)
+	}
+	if beUint32(raw) != entry {
+		t.Fatalf("on-disk offset-table entry = %#x, want %#x (in-memory copy)", beUint32(raw), entry)
+	}
+
+	ts := make([]byte, 4)
+	if _, err := r.file.ReadAt(ts, sectorSize+int64(chunkIndex(3, 4))*4); err != nil {
+		t.Fatalf("ReadAt timestamp table: %v", err)
+	}
+	if beUint32(ts) == 0 {
+		t.Fatalf("timestamp table entry is zero after WriteChunk")
+	}
+}
+
+// TestSectorReuse writes a large chunk, shrinks it by overwriting with a small payload, then writes a second,
+// unrelated chunk and checks it lands in the sectors the shrink just freed rather than growing the file
+func TestSectorReuse(t *testing.T) {
+	r := mustOpen(t)
+
+	if err := r.WriteChunk(0, 0, chunkNBT(20000)); err != nil {
+		t.Fatalf("WriteChunk big: %v", err)
+	}
+	bigEntry := r.offsets[chunkIndex(0, 0)]
+	bigOffset := int64(bigEntry >> 8)
+	bigCount := int64(bigEntry & 0xFF)
+	if bigCount < 2 {
+		t.Fatalf("expected the 20000-byte filler chunk to need multiple sectors, got %d", bigCount)
+	}
+
+	info, err := r.file.Stat()
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	sizeAfterBig := info.Size()
+
+	if err := r.WriteChunk(0, 0, chunkNBT(10)); err != nil {
+		t.Fatalf("WriteChunk shrink: %v", err)
+	}
+	smallEntry := r.offsets[chunkIndex(0, 0)]
+	if int64(smallEntry&0xFF) >= bigCount {
+		t.Fatalf("shrunk chunk still occupies %d sectors, want fewer than %d", smallEntry&0xFF, bigCount)
+	}
+
+	if err := r.WriteChunk(1, 0, chunkNBT(10)); err != nil {
+		t.Fatalf("WriteChunk new: %v", err)
+	}
+	newEntry := r.offsets[chunkIndex(1, 0)]
+	newOffset := int64(newEntry >> 8)
+
+	if newOffset < bigOffset || newOffset >= bigOffset+bigCount {
+		t.Fatalf("new chunk at sector %d did not land in the range freed by the shrink ([%d, %d))", newOffset, bigOffset, bigOffset+bigCount)
+	}
+
+	info, err = r.file.Stat()
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size() > sizeAfterBig {
+		t.Fatalf("file grew from %d to %d bytes; expected the freed sectors to be reused instead", sizeAfterBig, info.Size())
+	}
+
+	got, err := r.ReadChunk(1, 0)
+	if err != nil {
+		t.Fatalf("ReadChunk(1,0): %v", err)
+	}
+	if !reflect.DeepEqual(*chunkNBT(10), *got) {
+		t.Fatalf("ReadChunk(1,0) mismatch after sector reuse:\n want %+v\n got  %+v", *chunkNBT(10), *got)
+	}
+}
+
+// TestExternalFileFallback writes a chunk large enough to need more than 255 sectors and checks it spills to a
+// "c.<x>.<z>.mcc" file alongside the region file, with only a 1-sector external-file marker left in-region, and that
+// ReadChunk transparently reassembles the original tree from the two files
+func TestExternalFileFallback(t *testing.T) {
+	dir := t.TempDir()
+	r, err := Open(filepath.Join(dir, "r.0.0.mca"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	r.Compression = CompressionUncompressed
+
+	// uncompressed and stored verbatim, so (255 sectors * 4096 bytes) of filler guarantees > 255 sectors needed
+	want := chunkNBT(255 * sectorSize)
+	if err := r.WriteChunk(2, 9, want); err != nil {
+		t.Fatalf("WriteChunk: %v", err)
+	}
+
+	entry := r.offsets[chunkIndex(2, 9)]
+	if entry&0xFF != 1 {
+		t.Fatalf("sectorCount = %d, want 1 for an external-file marker", entry&0xFF)
+	}
+
+	mccPath := r.mccPath(2, 9)
+	if !strings.HasSuffix(mccPath, "c.2.9.mcc") {
+		t.Fatalf("mccPath = %q, want a name ending in c.2.9.mcc", mccPath)
+	}
+
+	got, err := r.ReadChunk(2, 9)
+	if err != nil {
+		t.Fatalf("ReadChunk: %v", err)
+	}
+	if !reflect.DeepEqual(*want, *got) {
+		t.Fatalf("ReadChunk round-trip mismatch through external .mcc file")
+	}
+}
+
+// TestChunks checks Chunks() enumerates every written chunk, ordered by chunk index (z-major, then x)
+func TestChunks(t *testing.T) {
+	r := mustOpen(t)
+
+	want := []ChunkPos{{X: 1, Z: 0}, {X: 0, Z: 1}, {X: 5, Z: 1}}
+	for _, c := range want {
+		if err := r.WriteChunk(c.X, c.Z, chunkNBT(10)); err != nil {
+			t.Fatalf("WriteChunk(%d,%d): %v", c.X, c.Z, err)
+		}
+	}
+
+	got := r.Chunks()
+	wantOrdered := []ChunkPos{{X: 1, Z: 0}, {X: 0, Z: 1}, {X: 5, Z: 1}}
+	if !reflect.DeepEqual(got, wantOrdered) {
+		t.Fatalf("Chunks() = %+v, want %+v", got, wantOrdered)
+	}
+}
@@ -0,0 +1,758 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  SNBT : the stringified, human-readable form of NBT  //////////////////////////////////////////////////////////////////////
+//
+// SNBT is the text form Minecraft uses wherever a player (or a datapack) writes NBT by hand : the `/data`, `/give`, and
+// `/summon` commands, and the contents of datapack function files; structurally it is the same tree as binary NBT, just
+// written as compounds `{key:value,...}`, lists `[v1,v2,...]`, and typed arrays `[B;1b,2b,...]` / `[I;...]` / `[L;...]`,
+// with numeric suffixes picking the scalar TAG : `b` byte, `s` short, `L` long, `f` float, `d` double; a bare integer
+// defaults to TAG_Int and a bare decimal defaults to TAG_Double
+//
+// ParseSNBT is a small hand-written recursive-descent scanner, rather than a regexp-driven one, so that malformed input
+// produces a line:col position instead of just "no match"
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  ParseSNBT  ////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SyntaxError reports the line and column (both 1-based) at which ParseSNBT gave up, along with a human-readable reason
+//
+type SyntaxError struct {
+	Line, Col int
+	Msg       string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("nbt: ParseSNBT: %d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// unquotedIdentChars is the character class unquoted keys and unquoted (bare) string values are allowed to use
+const unquotedIdentChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_.+-"
+
+// ParseSNBT parses s, the stringified-NBT text format Minecraft uses in /data, /give, and datapacks, and returns the
+// equivalent NBT tree; the root value is whatever s describes (usually a TAG_Compound, but not required to be)
+//
+func ParseSNBT(s string) (*NBT, error) {
+	p := &snbtParser{src: []rune(s), line: 1, col: 1}
+
+	p.skipSpace()
+	n, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if !p.atEnd() {
+		return nil, p.errorf("unexpected trailing data")
+	}
+
+	return n, nil
+}
+
+type snbtParser struct {
+	src  []rune
+	pos  int
+	line int
+	col  int
+}
+
+func (p *snbtParser) atEnd() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *snbtParser) peek() (rune, bool) {
+	if p.atEnd() {
+		return 0, false
+	}
+	return p.src[p.pos], true
+}
+
+func (p *snbtParser) advance() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+	return r
+}
+
+func (p *snbtParser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Line: p.line, Col: p.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *snbtParser) skipSpace() {
+	for {
+		r, ok := p.peek()
+		if !ok || !(r == ' ' || r == '\t' || r == '\r' || r == '\n') {
+			return
+		}
+		p.advance()
+	}
+}
+
+// parseValue parses one SNBT value : a compound, a list, a typed array, a quoted string, or a bare token (number or
+// unquoted string)
+//
+func (p *snbtParser) parseValue() (*NBT, error) {
+	r, ok := p.peek()
+	if !ok {
+		return nil, p.errorf("unexpected end of input, expected a value")
+	}
+
+	switch {
+	case r == '{':
+		return p.parseCompound()
+	case r == '[':
+		return p.parseListOrArray()
+	case r == '"' || r == '\'':
+		s, err := p.parseQuotedString(r)
+		if err != nil {
+			return nil, err
+		}
+		return &NBT{Type: TAG_String, Data: s}, nil
+	default:
+		tok, err := p.scanBareToken()
+		if err != nil {
+			return nil, err
+		}
+		return p.classifyScalar(tok)
+	}
+}
+
+func (p *snbtParser) parseCompound() (*NBT, error) {
+	p.advance() // '{'
+
+	var elems []NBT
+
+	p.skipSpace()
+	if r, ok := p.peek(); ok && r == '}' {
+		p.advance()
+		return &NBT{Type: TAG_Compound, Size: 0, Data: elems}, nil
+	}
+
+	for {
+		p.skipSpace()
+
+		key, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		if r, ok := p.peek(); !ok || r != ':' {
+			return nil, p.errorf("expected ':' after compound key %q", key)
+		}
+		p.advance()
+		p.skipSpace()
+
+		val, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		val.Name = key
+		elems = append(elems, *val)
+
+		p.skipSpace()
+		r, ok := p.peek()
+		if !ok {
+			return nil, p.errorf("unexpected end of input inside compound, expected ',' or '}'")
+		}
+		if r == ',' {
+			p.advance()
+			continue
+		}
+		if r == '}' {
+			p.advance()
+			break
+		}
+		return nil, p.errorf("expected ',' or '}', found %q", string(r))
+	}
+
+	return &NBT{Type: TAG_Compound, Size: uint32(len(elems)), Data: elems}, nil
+}
+
+// parseKey reads a compound key, either a quoted string or a bare run of unquotedIdentChars
+func (p *snbtParser) parseKey() (string, error) {
+	r, ok := p.peek()
+	if !ok {
+		return "", p.errorf("unexpected end of input, expected a compound key")
+	}
+	if r == '"' || r == '\'' {
+		return p.parseQuotedString(r)
+	}
+
+	start := p.pos
+	for {
+		r, ok := p.peek()
+		if !ok || !strings.ContainsRune(unquotedIdentChars, r) {
+			break
+		}
+		p.advance()
+	}
+	if p.pos == start {
+		return "", p.errorf("expected a compound key, found %q", string(r))
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+// parseListOrArray handles '[', distinguishing a typed array ("[B;", "[I;", "[L;") from an ordinary TAG_List by looking
+// one token ahead before committing
+//
+func (p *snbtParser) parseListOrArray() (*NBT, error) {
+	p.advance() // '['
+
+	save := p.pos
+	saveLine, saveCol := p.line, p.col
+
+	p.skipSpace()
+	if r, ok := p.peek(); ok && (r == 'B' || r == 'I' || r == 'L') {
+		p.advance()
+		p.skipSpace()
+		if r2, ok := p.peek(); ok && r2 == ';' {
+			p.advance()
+			return p.parseTypedArray(r)
+		}
+	}
+
+	// not a typed array after all; rewind and parse as a plain list
+	p.pos, p.line, p.col = save, saveLine, saveCol
+	return p.parseList()
+}
+
+func (p *snbtParser) parseList() (*NBT, error) {
+	var elems []NBT
+	var elemType NBTTAG = TAG_End
+
+	p.skipSpace()
+	if r, ok := p.peek(); ok && r == ']' {
+		p.advance()
+		return &NBT{Type: TAG_List, List: TAG_End, Size: 0, Data: elems}, nil
+	}
+
+	for {
+		p.skipSpace()
+
+		val, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if elemType == TAG_End {
+			elemType = val.Type
+		} else if val.Type != elemType {
+			return nil, p.errorf("list elements must share a single type, found %s after %s", NBTTAGName[val.Type], NBTTAGName[elemType])
+		}
+
+		// list elements are nameless in the NBT encoding; writeNBTData recognizes the "LISTELEM" sentinel
+		// name to skip writing a type byte and name for each element (see nbt.go)
+		val.Name = "LISTELEM"
+		elems = append(elems, *val)
+
+		p.skipSpace()
+		r, ok := p.peek()
+		if !ok {
+			return nil, p.errorf("unexpected end of input inside list, expected ',' or ']'")
+		}
+		if r == ',' {
+			p.advance()
+			continue
+		}
+		if r == ']' {
+			p.advance()
+			break
+		}
+		return nil, p.errorf("expected ',' or ']', found %q", string(r))
+	}
+
+	return &NBT{Type: TAG_List, List: elemType, Size: uint32(len(elems)), Data: elems}, nil
+}
+
+// parseTypedArray parses the comma-separated element list of a "[B;...]", "[I;...]", or "[L;...]" array; an element's
+// own scalar suffix, if present, is ignored, since the array's letter already fixes the element width
+//
+func (p *snbtParser) parseTypedArray(kind rune) (*NBT, error) {
+	var bitSize int
+	switch kind {
+	case 'B':
+		bitSize = 8
+	case 'I':
+		bitSize = 32
+	default: // 'L'
+		bitSize = 64
+	}
+
+	var longs []int64
+
+	p.skipSpace()
+	if r, ok := p.peek(); ok && r == ']' {
+		p.advance()
+	} else {
+		for {
+			p.skipSpace()
+
+			tok, err := p.scanBareToken()
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := parseArrayElement(tok, bitSize)
+			if err != nil {
+				return nil, p.errorf("%s", err)
+			}
+			longs = append(longs, v)
+
+			p.skipSpace()
+			r, ok := p.peek()
+			if !ok {
+				return nil, p.errorf("unexpected end of input inside array, expected ',' or ']'")
+			}
+			if r == ',' {
+				p.advance()
+				continue
+			}
+			if r == ']' {
+				p.advance()
+				break
+			}
+			return nil, p.errorf("expected ',' or ']', found %q", string(r))
+		}
+	}
+
+	switch kind {
+	case 'B':
+		bytes := make([]byte, len(longs))
+		for i, v := range longs {
+			bytes[i] = byte(int8(v))
+		}
+		return &NBT{Type: TAG_Byte_Array, Size: uint32(len(bytes)), Data: bytes}, nil
+	case 'I':
+		ints := make([]int32, len(longs))
+		for i, v := range longs {
+			ints[i] = int32(v)
+		}
+		return &NBT{Type: TAG_Int_Array, Size: uint32(len(ints)), Data: ints}, nil
+	default: // 'L'
+		return &NBT{Type: TAG_Long_Array, Size: uint32(len(longs)), Data: longs}, nil
+	}
+}
+
+// parseArrayElement strips an optional trailing b/s/L/f/d suffix (ignored, as described above) and parses the
+// remainder as a base-10 integer of the given bitSize, so an element too wide for the array's element type (8 for
+// "[B;...]", 32 for "[I;...]", 64 for "[L;...]") errors the same way an out-of-range bare scalar suffix does
+func parseArrayElement(tok string, bitSize int) (int64, error) {
+	numPart := tok
+	if n := len(tok); n > 0 {
+		switch tok[n-1] {
+		case 'b', 'B', 's', 'S', 'l', 'L', 'f', 'F', 'd', 'D':
+			numPart = tok[:n-1]
+		}
+	}
+	v, err := strconv.ParseInt(numPart, 10, bitSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array element %q: %w", tok, err)
+	}
+	return v, nil
+}
+
+// parseQuotedString reads a '"'- or '\''-delimited string, honoring \" \\ (and the matching-quote escape for the other
+// quote character) as escape sequences
+func (p *snbtParser) parseQuotedString(quote rune) (string, error) {
+	p.advance() // opening quote
+
+	var b strings.Builder
+	for {
+		r, ok := p.peek()
+		if !ok {
+			return "", p.errorf("unterminated string literal")
+		}
+		if r == quote {
+			p.advance()
+			return b.String(), nil
+		}
+		if r == '\\' {
+			p.advance()
+			esc, ok := p.peek()
+			if !ok {
+				return "", p.errorf("unterminated escape sequence")
+			}
+			switch esc {
+			case '\\', '"', '\'':
+				b.WriteRune(esc)
+			default:
+				return "", p.errorf("invalid escape sequence '\\%c'", esc)
+			}
+			p.advance()
+			continue
+		}
+		b.WriteRune(r)
+		p.advance()
+	}
+}
+
+// scanBareToken reads an unquoted run of characters up to the next structural delimiter (',', '}', ']', ':', or
+// whitespace) : this covers both bare numbers and bare (unquoted) strings
+func (p *snbtParser) scanBareToken() (string, error) {
+	start := p.pos
+	for {
+		r, ok := p.peek()
+		if !ok || r == ',' || r == '}' || r == ']' || r == ':' || r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			break
+		}
+		p.advance()
+	}
+	if p.pos == start {
+		r, _ := p.peek()
+		return "", p.errorf("unexpected character %q, expected a value", string(r))
+	}
+	return string(p.src[start:p.pos]), nil
+}
+
+// classifyScalar turns a bare token into the scalar NBT it denotes : a suffixed or bare number, or (failing that) an
+// unquoted string
+func (p *snbtParser) classifyScalar(tok string) (*NBT, error) {
+	numPart, suffix := tok, byte(0)
+	if n := len(tok); n > 1 {
+		switch tok[n-1] {
+		case 'b', 'B', 's', 'S', 'l', 'L', 'f', 'F', 'd', 'D':
+			if looksNumeric(tok[:n-1]) {
+				numPart, suffix = tok[:n-1], tok[n-1]
+			}
+		}
+	}
+
+	if suffix == 0 && !looksNumeric(numPart) {
+		for _, r := range tok {
+			if !strings.ContainsRune(unquotedIdentChars, r) {
+				return nil, p.errorf("unexpected token %q, expected a value", tok)
+			}
+		}
+		return &NBT{Type: TAG_String, Data: tok}, nil
+	}
+
+	switch suffix {
+	case 'b', 'B':
+		i, err := strconv.ParseInt(numPart, 10, 8)
+		if err != nil {
+			return nil, p.errorf("invalid byte %q: %s", tok, err)
+		}
+		return &NBT{Type: TAG_Byte, Data: byte(int8(i))}, nil
+
+	case 's', 'S':
+		i, err := strconv.ParseInt(numPart, 10, 16)
+		if err != nil {
+			return nil, p.errorf("invalid short %q: %s", tok, err)
+		}
+		return &NBT{Type: TAG_Short, Data: int16(i)}, nil
+
+	case 'l', 'L':
+		i, err := strconv.ParseInt(numPart, 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid long %q: %s", tok, err)
+		}
+		return &NBT{Type: TAG_Long, Data: i}, nil
+
+	case 'f', 'F':
+		f, err := strconv.ParseFloat(numPart, 32)
+		if err != nil {
+			return nil, p.errorf("invalid float %q: %s", tok, err)
+		}
+		return &NBT{Type: TAG_Float, Data: float32(f)}, nil
+
+	case 'd', 'D':
+		f, err := strconv.ParseFloat(numPart, 64)
+		if err != nil {
+			return nil, p.errorf("invalid double %q: %s", tok, err)
+		}
+		return &NBT{Type: TAG_Double, Data: f}, nil
+
+	default: // no suffix : bare decimals default to TAG_Double, bare integers to TAG_Int
+		if strings.ContainsAny(numPart, ".eE") {
+			f, err := strconv.ParseFloat(numPart, 64)
+			if err != nil {
+				return nil, p.errorf("invalid number %q: %s", tok, err)
+			}
+			return &NBT{Type: TAG_Double, Data: f}, nil
+		}
+		i, err := strconv.ParseInt(numPart, 10, 32)
+		if err != nil {
+			return nil, p.errorf("invalid number %q: %s", tok, err)
+		}
+		return &NBT{Type: TAG_Int, Data: int32(i)}, nil
+	}
+}
+
+// looksNumeric reports whether s is a base-10 integer or decimal, optionally signed and optionally in exponent form;
+// it does not itself check range, that is left to the strconv call that follows it
+func looksNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	i := 0
+	if s[i] == '-' || s[i] == '+' {
+		i++
+	}
+
+	digitsBefore := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+		digitsBefore++
+	}
+
+	if i < len(s) && s[i] == '.' {
+		i++
+		digitsAfter := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+			digitsAfter++
+		}
+		if digitsBefore == 0 && digitsAfter == 0 {
+			return false
+		}
+	} else if digitsBefore == 0 {
+		return false
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		expDigits := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+			expDigits++
+		}
+		if expDigits == 0 {
+			return false
+		}
+	}
+
+	return i == len(s)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  MarshalSNBT  //////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// MarshalSNBT renders n as compact stringified NBT : no whitespace beyond what a value itself requires
+//
+func MarshalSNBT(n *NBT) (string, error) {
+	var b strings.Builder
+	w := &snbtWriter{b: &b}
+	if err := w.writeValue(n); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// MarshalSNBTIndent renders n as stringified NBT, with each nested compound/list/array element on its own line,
+// indented by repeating indent once per nesting level, the way json.MarshalIndent works
+//
+func MarshalSNBTIndent(n *NBT, indent string) (string, error) {
+	var b strings.Builder
+	w := &snbtWriter{b: &b, indent: indent, pretty: true}
+	if err := w.writeValue(n); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+type snbtWriter struct {
+	b      *strings.Builder
+	indent string
+	pretty bool
+	depth  int
+}
+
+func (w *snbtWriter) newline() {
+	if !w.pretty {
+		return
+	}
+	w.b.WriteByte('\n')
+	for i := 0; i < w.depth; i++ {
+		w.b.WriteString(w.indent)
+	}
+}
+
+func (w *snbtWriter) writeValue(n *NBT) error {
+	switch n.Type {
+	case TAG_Byte:
+		fmt.Fprintf(w.b, "%db", int8(n.Data.(byte)))
+	case TAG_Short:
+		fmt.Fprintf(w.b, "%ds", n.Data.(int16))
+	case TAG_Int:
+		fmt.Fprintf(w.b, "%d", n.Data.(int32))
+	case TAG_Long:
+		fmt.Fprintf(w.b, "%dL", n.Data.(int64))
+	case TAG_Float:
+		fmt.Fprintf(w.b, "%sf", strconv.FormatFloat(float64(n.Data.(float32)), 'g', -1, 32))
+	case TAG_Double:
+		// a bare decimal defaults to TAG_Double on parse, but FormatFloat renders a whole number like 64.0 as "64",
+		// which would re-parse as TAG_Int; append an explicit 'd' suffix whenever the formatting doesn't already
+		// contain a '.' or exponent to keep MarshalSNBT -> ParseSNBT round-trip lossless
+		s := strconv.FormatFloat(n.Data.(float64), 'g', -1, 64)
+		w.b.WriteString(s)
+		if !strings.ContainsAny(s, ".eE") {
+			w.b.WriteByte('d')
+		}
+	case TAG_String:
+		w.writeQuotedString(n.Data.(string))
+
+	case TAG_Byte_Array:
+		w.writeByteArray(n.Data.([]byte))
+	case TAG_Int_Array:
+		w.writeIntArray(n.Data.([]int32))
+	case TAG_Long_Array:
+		w.writeLongArray(n.Data.([]int64))
+
+	case TAG_List:
+		return w.writeList(n)
+	case TAG_Compound:
+		return w.writeCompound(n)
+
+	default:
+		return fmt.Errorf("nbt: MarshalSNBT: cannot render %s as SNBT", NBTTAGName[n.Type])
+	}
+
+	return nil
+}
+
+func (w *snbtWriter) writeByteArray(elems []byte) {
+	w.b.WriteString("[B;")
+	w.depth++
+	for i, v := range elems {
+		if i > 0 {
+			w.b.WriteByte(',')
+		}
+		w.newline()
+		fmt.Fprintf(w.b, "%db", int8(v))
+	}
+	w.depth--
+	if len(elems) > 0 {
+		w.newline()
+	}
+	w.b.WriteByte(']')
+}
+
+func (w *snbtWriter) writeIntArray(elems []int32) {
+	w.b.WriteString("[I;")
+	w.depth++
+	for i, v := range elems {
+		if i > 0 {
+			w.b.WriteByte(',')
+		}
+		w.newline()
+		fmt.Fprintf(w.b, "%d", v)
+	}
+	w.depth--
+	if len(elems) > 0 {
+		w.newline()
+	}
+	w.b.WriteByte(']')
+}
+
+func (w *snbtWriter) writeLongArray(elems []int64) {
+	w.b.WriteString("[L;")
+	w.depth++
+	for i, v := range elems {
+		if i > 0 {
+			w.b.WriteByte(',')
+		}
+		w.newline()
+		fmt.Fprintf(w.b, "%dL", v)
+	}
+	w.depth--
+	if len(elems) > 0 {
+		w.newline()
+	}
+	w.b.WriteByte(']')
+}
+
+func (w *snbtWriter) writeList(n *NBT) error {
+	elems := n.Data.([]NBT)
+
+	w.b.WriteByte('[')
+	w.depth++
+	for i := range elems {
+		if i > 0 {
+			w.b.WriteByte(',')
+		}
+		w.newline()
+		if err := w.writeValue(&elems[i]); err != nil {
+			return err
+		}
+	}
+	w.depth--
+	if len(elems) > 0 {
+		w.newline()
+	}
+	w.b.WriteByte(']')
+
+	return nil
+}
+
+func (w *snbtWriter) writeCompound(n *NBT) error {
+	elems := n.Data.([]NBT)
+
+	w.b.WriteByte('{')
+	w.depth++
+	for i := range elems {
+		if i > 0 {
+			w.b.WriteByte(',')
+		}
+		w.newline()
+		w.writeKey(elems[i].Name)
+		w.b.WriteByte(':')
+		if w.pretty {
+			w.b.WriteByte(' ')
+		}
+		if err := w.writeValue(&elems[i]); err != nil {
+			return err
+		}
+	}
+	w.depth--
+	if len(elems) > 0 {
+		w.newline()
+	}
+	w.b.WriteByte('}')
+
+	return nil
+}
+
+// writeKey writes key unquoted when it matches unquotedIdentChars and is non-empty, and double-quoted otherwise
+func (w *snbtWriter) writeKey(key string) {
+	if key != "" {
+		bare := true
+		for _, r := range key {
+			if !strings.ContainsRune(unquotedIdentChars, r) {
+				bare = false
+				break
+			}
+		}
+		if bare {
+			w.b.WriteString(key)
+			return
+		}
+	}
+	w.writeQuotedString(key)
+}
+
+func (w *snbtWriter) writeQuotedString(s string) {
+	w.b.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			w.b.WriteByte('\\')
+		}
+		w.b.WriteRune(r)
+	}
+	w.b.WriteByte('"')
+}
@@ -0,0 +1,92 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  SNBT parse/serialize round-trip tests  ///////////////////////////////////////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestParseSNBTErrors(t *testing.T) {
+	cases := []string{
+		`{`,
+		`{foo}`,
+		`{foo:}`,
+		`[1,"a"]`,
+		`300b`,
+		`[B;300]`,
+		`"unterminated`,
+	}
+
+	for _, s := range cases {
+		if _, err := ParseSNBT(s); err == nil {
+			t.Errorf("ParseSNBT(%q): expected an error, got nil", s)
+		}
+	}
+}
+
+// binaryNormalize writes n out and reads it back (Java variant), which is how we get a canonical comparison point
+// for a tree that may have come from SNBT text with Size fields left unset
+func binaryNormalize(t *testing.T, n *NBT) NBT {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := WriteNBTData(&buf, n, Java); err != nil {
+		t.Fatalf("WriteNBTData: %v", err)
+	}
+
+	got, err := ReadNBTData(bytes.NewReader(buf.Bytes()), TAG_NULL, "", Java)
+	if err != nil {
+		t.Fatalf("ReadNBTData: %v", err)
+	}
+	return got
+}
+
+// FuzzSNBTRoundTrip round-trips SNBT text through the binary encoding and back to SNBT text: ParseSNBT -> (write,
+// read) -> MarshalSNBT -> ParseSNBT -> (write, read), and checks that both binary forms agree; this is the
+// requested SNBT -> binary -> SNBT coverage, using the binary encoding as the canonical comparison point since
+// re-parsed Size fields only get filled in once a tree has gone through ReadNBTData
+func FuzzSNBTRoundTrip(f *testing.F) {
+	seeds := []string{
+		`{}`,
+		`[]`,
+		`{Name:"Steve",Health:20.0f,Pos:[1.5d,64.0d,-2.25d]}`,
+		`{Data:[B;1b,2b,-3b],Ints:[I;1,-2,3],Longs:[L;1L,-2L]}`,
+		`{nested:{a:{b:{c:1}}}}`,
+		`{list:[{a:1},{a:2}]}`,
+		`{'quoted key':"value with \"escapes\""}`,
+		`{unquoted.key_1:-12345L}`,
+		`[1,2,3]`,
+		`{empty_list:[],empty_compound:{}}`,
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		orig, err := ParseSNBT(s)
+		if err != nil {
+			t.Skip("not valid SNBT")
+		}
+
+		norm1 := binaryNormalize(t, orig)
+
+		text, err := MarshalSNBT(&norm1)
+		if err != nil {
+			t.Fatalf("MarshalSNBT: %v", err)
+		}
+
+		reparsed, err := ParseSNBT(text)
+		if err != nil {
+			t.Fatalf("ParseSNBT(MarshalSNBT(x)) failed on %q: %v", text, err)
+		}
+
+		norm2 := binaryNormalize(t, reparsed)
+
+		if !reflect.DeepEqual(norm1, norm2) {
+			t.Fatalf("round-trip mismatch:\n input  %q\n text   %q\n norm1  %+v\n norm2  %+v", s, text, norm1, norm2)
+		}
+	})
+}
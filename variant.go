@@ -0,0 +1,323 @@
+package nbt
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  encoding variants  ////////////////////////////////////////////////////////////////////////////////////////////////////////
+//
+// Mojang ships three on-the-wire shapes of the same NBT tree structure described at the top of nbt.go :
+//
+//   - Java        : the original big-endian format documented in nbt.go; this is what ReadNBTData / WriteNBTData always did
+//     before this file existed, and remains the default
+//   - BedrockLE    : Bedrock Edition's disk format (level.dat, and the leveldb values in a Bedrock world); structurally
+//     identical to Java, but every fixed-width integer/float and every length prefix is little-endian, and TAG_String
+//     uses a uint16 LE length instead of Java's int16 BE length
+//   - BedrockNetwork : the variant Bedrock uses on the wire between client and server; same little-endian fixed-width
+//     fields as BedrockLE, except TAG_Int and array/list lengths are zig-zag VarInts (LEB128, 7 data bits per byte, MSB
+//     set means "another byte follows"; zig-zag maps signed n to unsigned via (n<<1)^(n>>31) so small negative numbers
+//     stay small), TAG_Long is a zig-zag VarLong, and TAG_String lengths are plain (non-zig-zag) unsigned VarInts
+//
+// ReadNBTData and WriteNBTData take a Variant argument and dispatch every byte-order-dependent read/write through the
+// codec for that Variant, so switching encodings is the one parameter, not a rewrite of the call site
+
+type Variant int
+
+const (
+	Java Variant = iota
+	BedrockLE
+	BedrockNetwork
+)
+
+func (v Variant) String() string {
+	switch v {
+	case Java:
+		return "Java"
+	case BedrockLE:
+		return "BedrockLE"
+	case BedrockNetwork:
+		return "BedrockNetwork"
+	}
+	return "Unknown"
+}
+
+// codec is the small interface that isolates every byte-order / varint decision from the tree-walking logic in
+// ReadNBTData and WriteNBTData; fields whose width and meaning never vary between variants (TAG_Byte) are not part of
+// it, since they need no dispatch at all
+//
+// every Read method takes a byteReader rather than a concrete type, since the fixed-width codecs need only io.Reader
+// but the network codec's VarInt reader also needs io.ByteReader; ReadNBTData guarantees one is always available by
+// wrapping a plain io.Reader in a *bufio.Reader up front
+//
+type codec interface {
+	ReadInt16(r byteReader) (int16, error)
+	WriteInt16(w io.Writer, v int16) error
+
+	ReadInt32(r byteReader) (int32, error)
+	WriteInt32(w io.Writer, v int32) error
+
+	ReadInt64(r byteReader) (int64, error)
+	WriteInt64(w io.Writer, v int64) error
+
+	ReadFloat32(r byteReader) (float32, error)
+	WriteFloat32(w io.Writer, v float32) error
+
+	ReadFloat64(r byteReader) (float64, error)
+	WriteFloat64(w io.Writer, v float64) error
+
+	// ReadSize / WriteSize handle the uint32 element counts used by TAG_List, TAG_Byte_Array, TAG_Int_Array, and
+	// TAG_Long_Array
+	ReadSize(r byteReader) (uint32, error)
+	WriteSize(w io.Writer, v uint32) error
+
+	// ReadStringLen / WriteStringLen handle the TAG_String length prefix, which is int16 in Java, uint16 in
+	// BedrockLE, and an unsigned VarInt in BedrockNetwork
+	ReadStringLen(r byteReader) (int, error)
+	WriteStringLen(w io.Writer, n int) error
+}
+
+func (v Variant) codec() codec {
+	switch v {
+	case BedrockLE:
+		return bedrockCodec{}
+	case BedrockNetwork:
+		return networkCodec{}
+	default:
+		return javaCodec{}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Java : big-endian, fixed-width  //////////////////////////////////////////////////////////////////////////////////////////
+
+type javaCodec struct{}
+
+func (javaCodec) ReadInt16(r byteReader) (v int16, err error) {
+	err = readScratch(r, 2, func(b []byte) { v = int16(binary.BigEndian.Uint16(b)) })
+	return
+}
+func (javaCodec) WriteInt16(w io.Writer, v int16) error {
+	return writeScratch(w, 2, func(b []byte) { binary.BigEndian.PutUint16(b, uint16(v)) })
+}
+
+func (javaCodec) ReadInt32(r byteReader) (v int32, err error) {
+	err = readScratch(r, 4, func(b []byte) { v = int32(binary.BigEndian.Uint32(b)) })
+	return
+}
+func (javaCodec) WriteInt32(w io.Writer, v int32) error {
+	return writeScratch(w, 4, func(b []byte) { binary.BigEndian.PutUint32(b, uint32(v)) })
+}
+
+func (javaCodec) ReadInt64(r byteReader) (v int64, err error) {
+	err = readScratch(r, 8, func(b []byte) { v = int64(binary.BigEndian.Uint64(b)) })
+	return
+}
+func (javaCodec) WriteInt64(w io.Writer, v int64) error {
+	return writeScratch(w, 8, func(b []byte) { binary.BigEndian.PutUint64(b, uint64(v)) })
+}
+
+func (javaCodec) ReadFloat32(r byteReader) (v float32, err error) {
+	err = readScratch(r, 4, func(b []byte) { v = math.Float32frombits(binary.BigEndian.Uint32(b)) })
+	return
+}
+func (javaCodec) WriteFloat32(w io.Writer, v float32) error {
+	return writeScratch(w, 4, func(b []byte) { binary.BigEndian.PutUint32(b, math.Float32bits(v)) })
+}
+
+func (javaCodec) ReadFloat64(r byteReader) (v float64, err error) {
+	err = readScratch(r, 8, func(b []byte) { v = math.Float64frombits(binary.BigEndian.Uint64(b)) })
+	return
+}
+func (javaCodec) WriteFloat64(w io.Writer, v float64) error {
+	return writeScratch(w, 8, func(b []byte) { binary.BigEndian.PutUint64(b, math.Float64bits(v)) })
+}
+
+func (javaCodec) ReadSize(r byteReader) (v uint32, err error) {
+	err = readScratch(r, 4, func(b []byte) { v = binary.BigEndian.Uint32(b) })
+	return
+}
+func (javaCodec) WriteSize(w io.Writer, v uint32) error {
+	return writeScratch(w, 4, func(b []byte) { binary.BigEndian.PutUint32(b, v) })
+}
+
+func (javaCodec) ReadStringLen(r byteReader) (v int, err error) {
+	err = readScratch(r, 2, func(b []byte) { v = int(int16(binary.BigEndian.Uint16(b))) })
+	return
+}
+func (javaCodec) WriteStringLen(w io.Writer, n int) error {
+	return writeScratch(w, 2, func(b []byte) { binary.BigEndian.PutUint16(b, uint16(n)) })
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  BedrockLE : little-endian, fixed-width, uint16 string length  ///////////////////////////////////////////////////////////////
+
+type bedrockCodec struct{}
+
+func (bedrockCodec) ReadInt16(r byteReader) (v int16, err error) {
+	err = readScratch(r, 2, func(b []byte) { v = int16(binary.LittleEndian.Uint16(b)) })
+	return
+}
+func (bedrockCodec) WriteInt16(w io.Writer, v int16) error {
+	return writeScratch(w, 2, func(b []byte) { binary.LittleEndian.PutUint16(b, uint16(v)) })
+}
+
+func (bedrockCodec) ReadInt32(r byteReader) (v int32, err error) {
+	err = readScratch(r, 4, func(b []byte) { v = int32(binary.LittleEndian.Uint32(b)) })
+	return
+}
+func (bedrockCodec) WriteInt32(w io.Writer, v int32) error {
+	return writeScratch(w, 4, func(b []byte) { binary.LittleEndian.PutUint32(b, uint32(v)) })
+}
+
+func (bedrockCodec) ReadInt64(r byteReader) (v int64, err error) {
+	err = readScratch(r, 8, func(b []byte) { v = int64(binary.LittleEndian.Uint64(b)) })
+	return
+}
+func (bedrockCodec) WriteInt64(w io.Writer, v int64) error {
+	return writeScratch(w, 8, func(b []byte) { binary.LittleEndian.PutUint64(b, uint64(v)) })
+}
+
+func (bedrockCodec) ReadFloat32(r byteReader) (v float32, err error) {
+	err = readScratch(r, 4, func(b []byte) { v = math.Float32frombits(binary.LittleEndian.Uint32(b)) })
+	return
+}
+func (bedrockCodec) WriteFloat32(w io.Writer, v float32) error {
+	return writeScratch(w, 4, func(b []byte) { binary.LittleEndian.PutUint32(b, math.Float32bits(v)) })
+}
+
+func (bedrockCodec) ReadFloat64(r byteReader) (v float64, err error) {
+	err = readScratch(r, 8, func(b []byte) { v = math.Float64frombits(binary.LittleEndian.Uint64(b)) })
+	return
+}
+func (bedrockCodec) WriteFloat64(w io.Writer, v float64) error {
+	return writeScratch(w, 8, func(b []byte) { binary.LittleEndian.PutUint64(b, math.Float64bits(v)) })
+}
+
+func (bedrockCodec) ReadSize(r byteReader) (v uint32, err error) {
+	err = readScratch(r, 4, func(b []byte) { v = binary.LittleEndian.Uint32(b) })
+	return
+}
+func (bedrockCodec) WriteSize(w io.Writer, v uint32) error {
+	return writeScratch(w, 4, func(b []byte) { binary.LittleEndian.PutUint32(b, v) })
+}
+
+func (bedrockCodec) ReadStringLen(r byteReader) (v int, err error) {
+	err = readScratch(r, 2, func(b []byte) { v = int(binary.LittleEndian.Uint16(b)) })
+	return
+}
+func (bedrockCodec) WriteStringLen(w io.Writer, n int) error {
+	return writeScratch(w, 2, func(b []byte) { binary.LittleEndian.PutUint16(b, uint16(n)) })
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  BedrockNetwork : little-endian, plus zig-zag VarInt/VarLong for Int/Long/sizes, unsigned VarInt string-length  /////////////
+
+type networkCodec struct {
+	bedrockCodec
+}
+
+func (networkCodec) ReadInt32(r byteReader) (int32, error) {
+	u, err := readVarint(r)
+	if err != nil {
+		return 0, err
+	}
+	return zigzagDecode32(uint32(u)), nil
+}
+func (networkCodec) WriteInt32(w io.Writer, v int32) error {
+	return writeVarint(w, uint64(zigzagEncode32(v)))
+}
+
+func (networkCodec) ReadInt64(r byteReader) (int64, error) {
+	u, err := readVarint(r)
+	if err != nil {
+		return 0, err
+	}
+	return zigzagDecode64(u), nil
+}
+func (networkCodec) WriteInt64(w io.Writer, v int64) error {
+	return writeVarint(w, zigzagEncode64(v))
+}
+
+// ReadSize / WriteSize encode array and list lengths the same way as TAG_Int : a zig-zag VarInt
+func (networkCodec) ReadSize(r byteReader) (uint32, error) {
+	u, err := readVarint(r)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(zigzagDecode32(uint32(u))), nil
+}
+func (networkCodec) WriteSize(w io.Writer, v uint32) error {
+	return writeVarint(w, uint64(zigzagEncode32(int32(v))))
+}
+
+// ReadStringLen / WriteStringLen use a plain (non-zig-zag) unsigned VarInt
+
+func (networkCodec) ReadStringLen(r byteReader) (int, error) {
+	u, err := readVarint(r)
+	return int(u), err
+}
+func (networkCodec) WriteStringLen(w io.Writer, n int) error {
+	return writeVarint(w, uint64(n))
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  VarInt / VarLong (LEB128) and zig-zag helpers  ////////////////////////////////////////////////////////////////////////////
+
+func readVarint(r byteReader) (uint64, error) {
+	var result uint64
+	var shift uint
+
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		result |= uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+	}
+
+	return result, nil
+}
+
+// writeVarint's length isn't known up front, so it doesn't go through the shared scratch-array pool; each iteration
+// only ever touches one stack byte, so there is nothing to pool
+func writeVarint(w io.Writer, v uint64) error {
+	var buf [1]byte
+	for {
+		b := byte(v & 0x7F)
+		v >>= 7
+		if v != 0 {
+			b |= 0x80
+		}
+		buf[0] = b
+		if _, err := w.Write(buf[:]); err != nil {
+			return err
+		}
+		if v == 0 {
+			return nil
+		}
+	}
+}
+
+func zigzagEncode32(n int32) uint32 {
+	return uint32((n << 1) ^ (n >> 31))
+}
+
+func zigzagDecode32(u uint32) int32 {
+	return int32(u>>1) ^ -int32(u&1)
+}
+
+func zigzagEncode64(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+func zigzagDecode64(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
@@ -0,0 +1,128 @@
+package nbt
+
+///////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+//  Variant (Java / BedrockLE / BedrockNetwork) round-trip and fixture tests  ////////////////////////////////////////////////
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// sampleTree builds a small but representative NBT tree exercising every TAG type the codecs touch: fixed-width
+// scalars, a string, a byte/int/long array, and a nested TAG_List of TAG_Compound
+func sampleTree() *NBT {
+	return &NBT{
+		Type: TAG_Compound,
+		Name: "",
+		Size: 11,
+		Data: []NBT{
+			{Type: TAG_Byte, Name: "Flags", Data: byte(0x7f)},
+			{Type: TAG_Short, Name: "Short", Data: int16(-1234)},
+			{Type: TAG_Int, Name: "Int", Data: int32(-70000)},
+			{Type: TAG_Long, Name: "Long", Data: int64(-8019191920289085320)},
+			{Type: TAG_Float, Name: "Float", Data: float32(3.5)},
+			{Type: TAG_Double, Name: "Double", Data: 64.25},
+			{Type: TAG_String, Name: "Name", Size: 5, Data: "Steve"},
+			{Type: TAG_Byte_Array, Name: "Bytes", Size: 3, Data: []byte{1, 2, 0xfd}},
+			{Type: TAG_Int_Array, Name: "Ints", Size: 2, Data: []int32{1, -2}},
+			{Type: TAG_Long_Array, Name: "Longs", Size: 2, Data: []int64{1, -2}},
+			{
+				Type: TAG_List, List: TAG_Compound, Name: "Items", Size: 2,
+				Data: []NBT{
+					{Type: TAG_Compound, Name: "LISTELEM", Size: 1, Data: []NBT{
+						{Type: TAG_String, Name: "id", Size: 15, Data: "minecraft:torch"},
+					}},
+					{Type: TAG_Compound, Name: "LISTELEM", Size: 1, Data: []NBT{
+						{Type: TAG_String, Name: "id", Size: 25, Data: "minecraft:diamond_pickaxe"},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// TestVariantRoundTrip table-drives the same tree through all three Mojang encodings (Java, BedrockLE,
+// BedrockNetwork), writing and reading each back, and checking the result matches the original
+func TestVariantRoundTrip(t *testing.T) {
+	variants := []Variant{Java, BedrockLE, BedrockNetwork}
+
+	for _, variant := range variants {
+		variant := variant
+		t.Run(variant.String(), func(t *testing.T) {
+			want := sampleTree()
+
+			var buf bytes.Buffer
+			if err := WriteNBTData(&buf, want, variant); err != nil {
+				t.Fatalf("WriteNBTData: %v", err)
+			}
+
+			got, err := ReadNBTData(bytes.NewReader(buf.Bytes()), TAG_NULL, "", variant)
+			if err != nil {
+				t.Fatalf("ReadNBTData: %v", err)
+			}
+
+			if !reflect.DeepEqual(*want, got) {
+				t.Fatalf("round-trip mismatch for %s:\n want %+v\n got  %+v", variant, *want, got)
+			}
+		})
+	}
+}
+
+// TestVariantFixtures checks each edition's codec against a small hand-computed byte fixture for a single TAG_Short,
+// the simplest tag whose wire form actually differs (byte order) between editions
+func TestVariantFixtures(t *testing.T) {
+	cases := []struct {
+		variant Variant
+		fixture []byte // type byte, name-length+name, payload, for an unnamed-root-free single TAG_Short named "S" with value 0x0102
+	}{
+		{Java, []byte{byte(TAG_Short), 0x00, 0x01, 'S', 0x01, 0x02}},
+		{BedrockLE, []byte{byte(TAG_Short), 0x01, 0x00, 'S', 0x02, 0x01}},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.variant.String(), func(t *testing.T) {
+			got, err := ReadNBTData(bytes.NewReader(c.fixture), TAG_NULL, "", c.variant)
+			if err != nil {
+				t.Fatalf("ReadNBTData: %v", err)
+			}
+			if got.Type != TAG_Short || got.Name != "S" || got.Data.(int16) != 0x0102 {
+				t.Fatalf("fixture decode mismatch: got %+v", got)
+			}
+
+			var buf bytes.Buffer
+			if err := WriteNBTData(&buf, &got, c.variant); err != nil {
+				t.Fatalf("WriteNBTData: %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), c.fixture) {
+				t.Fatalf("fixture re-encode mismatch:\n want % x\n got  % x", c.fixture, buf.Bytes())
+			}
+		})
+	}
+}
+
+// TestNetworkSizeIsZigZagVarInt pins down that BedrockNetwork encodes TAG_List/array lengths as zig-zag VarInts, the
+// same as TAG_Int; a size of 64 is the smallest value whose encoded length tells the two encodings apart (64 fits in
+// one plain VarInt byte, but zig-zag(64) == 128, which needs two)
+func TestNetworkSizeIsZigZagVarInt(t *testing.T) {
+	c := BedrockNetwork.codec()
+
+	var buf bytes.Buffer
+	if err := c.WriteSize(&buf, 64); err != nil {
+		t.Fatalf("WriteSize: %v", err)
+	}
+
+	want := []byte{0x80, 0x01}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("WriteSize(64) = % x, want % x (zig-zag VarInt, not plain)", buf.Bytes(), want)
+	}
+
+	got, err := c.ReadSize(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadSize: %v", err)
+	}
+	if got != 64 {
+		t.Fatalf("ReadSize = %d, want 64", got)
+	}
+}